@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/Arka-Lab/LoR/internal"
+)
+
+// runCluster starts one node process per trader on localhost. Flags after
+// the cluster's own are passed to every node.
+func runCluster(args []string) {
+	logger := internal.NewLogger(os.Stderr, internal.LogOptions{})
+	flags := flag.NewFlagSet("cluster", flag.ExitOnError)
+	nodesPtr := flags.Int("nodes", 5, "number of trader processes")
+	portPtr := flags.Int("port", 7000, "port of the first node, the others use the ports after it")
+	seedPtr := flags.Uint64("seed", 1, "seed of the first node, the others count up from it")
+	flags.Parse(args)
+
+	if *nodesPtr < 1 {
+		fatal(logger, "invalid flags", errors.New("number of nodes must be positive"))
+	}
+	// Parse the node flags here too, so mistakes fail before any process starts.
+	config := defaultDaemonConfig()
+	if err := nodeFlags("node", &config, new(internal.LogOptions)).Parse(flags.Args()); err != nil {
+		fatal(logger, "invalid node flags", err)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		fatal(logger, "error finding executable", err)
+	}
+	addresses := make([]string, *nodesPtr)
+	for i := range addresses {
+		addresses[i] = "127.0.0.1:" + strconv.Itoa(*portPtr+i)
+	}
+
+	stats := make([]internal.DaemonStats, *nodesPtr)
+	failed := make([]error, *nodesPtr)
+	var wg sync.WaitGroup
+	for i, address := range addresses {
+		peers := append(append([]string(nil), addresses[:i]...), addresses[i+1:]...)
+		nodeArgs := append([]string{"node",
+			"-listen", address,
+			"-peers", strings.Join(peers, ","),
+			"-seed", strconv.FormatUint(*seedPtr+uint64(i), 10),
+		}, flags.Args()...)
+
+		cmd := exec.Command(executable, nodeArgs...)
+		var stdout bytes.Buffer
+		cmd.Stdout = &stdout
+		stderr, err := cmd.StderrPipe()
+		if err != nil {
+			fatal(logger, "error starting node "+strconv.Itoa(i), err)
+		}
+		if err := cmd.Start(); err != nil {
+			fatal(logger, "error starting node "+strconv.Itoa(i), err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scanner := bufio.NewScanner(stderr)
+			for scanner.Scan() {
+				fmt.Fprintf(os.Stderr, "[node %d] %s\n", i, scanner.Text())
+			}
+			if err := cmd.Wait(); err != nil {
+				failed[i] = err
+			} else if err := json.Unmarshal(stdout.Bytes(), &stats[i]); err != nil {
+				failed[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "node\ttrader\ttraders\tminted\tcoins\tsubmitted\taccepted\tfractals\tvotes\terrors\tdropped")
+	for i, s := range stats {
+		if failed[i] != nil {
+			fmt.Fprintf(writer, "%d\tfailed: %v\n", i, failed[i])
+			continue
+		}
+		fmt.Fprintf(writer, "%d\t%.8s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n", i, s.TraderID, s.Traders, s.Minted, s.Coins, s.Submitted, s.Accepted, s.Fractals, s.Votes, s.Errors, s.Dropped)
+	}
+	writer.Flush()
+
+	for _, err := range failed {
+		if err != nil {
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Arka-Lab/LoR/internal"
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+func runGrind(args []string) {
+	params := pkg.DefaultParams()
+	flags := flag.NewFlagSet("grind", flag.ExitOnError)
+	tradersPtr := flags.Int("trader", 500, "number of traders")
+	membersPtr := flags.Int("coalition", 150, "number of coalition members")
+	flags.IntVar(&params.VerificationMin, "team-min", params.VerificationMin, "minimum verification team size")
+	flags.IntVar(&params.VerificationMax, "team-max", params.VerificationMax, "maximum verification team size")
+	budgetsPtr := flags.String("budgets", "1,2,4,8,16,32,64,128", "comma-separated grind budgets to compare")
+	trialsPtr := flags.Int("trials", 2000, "number of simulated submissions per budget")
+	seedPtr := flags.Uint64("seed", 1, "random seed")
+	formatPtr := flags.String("format", "text", "output format of the report (text, json or csv)")
+	var logging internal.LogOptions
+	logFlags(flags, &logging)
+	flags.Parse(args)
+	logger := internal.NewLogger(os.Stderr, logging)
+
+	var budgets []int
+	for _, value := range strings.Split(*budgetsPtr, ",") {
+		budget, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || budget < 1 {
+			fatal(logger, "invalid flags", fmt.Errorf("grind budget %q must be a positive integer", value))
+		}
+		budgets = append(budgets, budget)
+	}
+
+	rows, err := internal.GrindReport(params, *tradersPtr, *membersPtr, budgets, *trialsPtr, *seedPtr)
+	if err != nil {
+		fatal(logger, "error computing grind report", err)
+	}
+	if err := internal.WriteGrindReport(os.Stdout, rows, *formatPtr); err != nil {
+		fatal(logger, "error writing grind report", err)
+	}
+}
@@ -1,96 +1,271 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/Arka-Lab/LoR/internal"
-	"github.com/Arka-Lab/LoR/pkg"
 )
 
-func ParseFlags() (int, time.Duration, int, int, int, string, string) {
-	typesPtr := flag.Int("type", 3, "number of coin types")
-	runTimePtr := flag.Int("time", 60, "run time in seconds")
-	tradersPtr := flag.Int("trader", 100, "number of traders")
-	randomsPtr := flag.Int("random", 0, "number of random traders")
-	badsPtr := flag.Int("bad", 0, "number of bad traders")
-	alphaPtr := flag.Float64("alpha", pkg.BadBehavior, "bad behavior percentage")
-	saveTohPtr := flag.String("save-to", "system.json", "file path to save system")
+type Options struct {
+	Scenario     internal.Scenario
+	SaveTo       string
+	LoadFrom     string
+	CheckpointTo string
+	ResumeFrom   string
+	Journal      string
+	Format       string
+	HTTP         string
+	Logging      internal.LogOptions
+}
+
+// logFlags registers the logging flags shared by the commands that run
+// traders.
+func logFlags(flags *flag.FlagSet, options *internal.LogOptions) {
+	flags.Var(&options.Levels, "log-level", "comma-separated log levels, bare for every component or component=level ("+strings.Join(internal.Components(), ", ")+")")
+	flags.BoolVar(&options.JSON, "log-json", options.JSON, "write log records as JSON lines")
+}
+
+// serveHTTP serves the live stats of system and lookups into it until the
+// process exits.
+func serveHTTP(logger *slog.Logger, address string, system *internal.System) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		fatal(logger, "error starting the http server", err)
+	}
+	logger.Info("serving live stats", "address", listener.Addr().String())
+	go func() {
+		if err := http.Serve(listener, system.Handler()); err != nil {
+			logger.Error("http server stopped", "error", err)
+		}
+	}()
+}
+
+// fatal logs err and exits.
+func fatal(logger *slog.Logger, message string, err error) {
+	logger.Error(message, "error", err)
+	os.Exit(1)
+}
+
+func ParseFlags() Options {
+	scenario := internal.DefaultScenario()
+	params := &scenario.Params
+
+	scenarioPtr := flag.String("scenario", "", "file path to load a JSON scenario from, or YAML with a .yaml or .yml extension (flags override it)")
+	flag.UintVar(&scenario.Types, "type", scenario.Types, "number of coin types")
+	flag.IntVar(&scenario.Time, "time", scenario.Time, "virtual run time in seconds")
+	flag.IntVar(&scenario.Traders, "trader", scenario.Traders, "number of traders")
+	flag.IntVar(&scenario.Randoms, "random", scenario.Randoms, "number of random traders")
+	flag.IntVar(&scenario.Bads, "bad", scenario.Bads, "number of bad traders")
+	flag.Var(&scenario.Behaviors, "behaviors", "comma-separated name=count list of trader behaviors besides -random and -bad")
+	flag.IntVar(&scenario.Sybils, "sybils", scenario.Sybils, "number of sybil principals")
+	flag.IntVar(&scenario.Wallets, "wallets", scenario.Wallets, "number of wallets each sybil principal controls")
+	flag.Uint64Var(&scenario.Seed, "seed", scenario.Seed, "random seed (0 picks one from the current time)")
+	flag.IntVar(&scenario.Faults.Latency, "latency", scenario.Faults.Latency, "mean message latency between traders in milliseconds")
+	flag.IntVar(&scenario.Faults.Jitter, "jitter", scenario.Faults.Jitter, "maximum extra delay per message in milliseconds")
+	flag.Float64Var(&scenario.Faults.Drop, "drop", scenario.Faults.Drop, "probability that a message is lost")
+	flag.Float64Var(&scenario.Faults.Duplicate, "duplicate", scenario.Faults.Duplicate, "probability that a message is delivered twice")
+	flag.Float64Var(&scenario.Faults.Reorder, "reorder", scenario.Faults.Reorder, "probability that a message is held back behind later ones (needs -latency or -jitter)")
+	flag.IntVar(&scenario.Faults.Timeout, "vote-timeout", scenario.Faults.Timeout, "milliseconds a submitter waits for votes (0 means half a round)")
+	flag.Var(&scenario.Faults.Partitions, "partitions", "comma-separated start:duration:fraction list of network partitions (milliseconds)")
+	flag.IntVar(&scenario.Gossip.Fanout, "fanout", scenario.Gossip.Fanout, "number of traders each coin is gossiped to per hop (0 sends it to every trader)")
+	flag.IntVar(&scenario.Gossip.TTL, "ttl", scenario.Gossip.TTL, "number of gossip hops a coin may take")
+	flag.IntVar(&scenario.Gossip.Pull, "pull", scenario.Gossip.Pull, "milliseconds between a trader's pulls of missed coins (0 disables pulls)")
+	flag.StringVar(&scenario.Topology.Kind, "topology", scenario.Topology.Kind, "overlay topology of the traders (complete, regular, small-world or scale-free)")
+	flag.IntVar(&scenario.Topology.Degree, "degree", scenario.Topology.Degree, "neighbors per trader in the overlay topology")
+	flag.Float64Var(&scenario.Topology.Rewire, "rewire", scenario.Topology.Rewire, "probability that a small-world edge is rewired")
+	flag.IntVar(&scenario.Sample, "sample-interval", scenario.Sample, "virtual seconds between metric samples (0 disables sampling)")
+	flag.Float64Var(&params.BadBehavior, "alpha", params.BadBehavior, "bad behavior percentage")
+	flag.IntVar(&params.FractalMin, "fractal-min", params.FractalMin, "minimum number of cooperation rings in a fractal ring")
+	flag.IntVar(&params.FractalMax, "fractal-max", params.FractalMax, "maximum number of cooperation rings in a fractal ring")
+	flag.Float64Var(&params.FractalPrize, "fractal-prize", params.FractalPrize, "prize paid per coin of a completed fractal ring")
+	flag.IntVar(&params.RoundsCount, "rounds", params.RoundsCount, "number of voting rounds per fractal ring")
+	flag.IntVar(&params.RoundLength, "round-length", params.RoundLength, "round length in milliseconds")
+	flag.IntVar(&params.VerificationMin, "team-min", params.VerificationMin, "minimum verification team size")
+	flag.IntVar(&params.VerificationMax, "team-max", params.VerificationMax, "maximum verification team size")
+	flag.IntVar(&params.BanCount, "ban", params.BanCount, "number of fractal rings a minority voter is banned for")
+	flag.IntVar(&params.GrindBudget, "grind-budget", params.GrindBudget, "number of first verification team members a grinder tries")
+	flag.BoolVar(&params.BeaconTeams, "beacon-teams", params.BeaconTeams, "derive the first verification team member from a shared beacon instead of the submitter's choice")
+	flag.StringVar(&params.Scheme, "scheme", params.Scheme, "signature scheme of trader keys and coin IDs (rsa-pss or ed25519)")
+	flag.IntVar(&params.KeySize, "key-size", params.KeySize, "RSA key size in bits")
+	saveTohPtr := flag.String("save-to", "system.json", "file path to save system (gzip-compressed if it ends in .gz)")
 	loadFromhPtr := flag.String("load-from", "", "file path to load system")
+	checkpointToPtr := flag.String("checkpoint-to", "", "file path to save a full checkpoint that can be resumed")
+	resumeFromPtr := flag.String("resume-from", "", "file path of a checkpoint to continue for another -time seconds")
+	journalPtr := flag.String("journal", "", "file path to append the event journal to (JSON Lines, gzip if it ends in .gz)")
+	formatPtr := flag.String("format", "text", "output format of the analysis (text, json or csv)")
+	httpPtr := flag.String("http", "", "address such as 127.0.0.1:8080 to serve live stats and trader, coin and fractal ring lookups on")
+	var logging internal.LogOptions
+	logFlags(flag.CommandLine, &logging)
 	flag.Parse()
+	logger := internal.NewLogger(os.Stderr, logging)
 
-	if *typesPtr < 1 {
-		log.Fatalf("Number of types must be positive\n")
-	} else if *tradersPtr < 1 {
-		log.Fatalf("Number of traders must be positive\n")
-	}
-	numTypes, numTraders := *typesPtr, *tradersPtr
+	if *scenarioPtr != "" {
+		overrides := make(map[string]string)
+		flag.Visit(func(f *flag.Flag) {
+			overrides[f.Name] = f.Value.String()
+		})
 
-	if *runTimePtr < 0 {
-		log.Fatalf("Run time must be non-negative\n")
+		loaded, err := internal.LoadScenario(*scenarioPtr)
+		if err != nil {
+			fatal(logger, "error loading scenario", err)
+		}
+		scenario = loaded
+		for name, value := range overrides {
+			if err := flag.Set(name, value); err != nil {
+				fatal(logger, "error applying flag "+name, err)
+			}
+		}
 	}
-	runTime := time.Duration(*runTimePtr) * time.Second
 
-	if *randomsPtr < 0 || *badsPtr < 0 {
-		log.Fatalf("Number of random and bad traders must be non-negative\n")
-	} else if *randomsPtr+*badsPtr > numTraders {
-		log.Fatalf("Number of random and bad traders must be less than the total number of traders\n")
+	if err := scenario.Validate(); err != nil {
+		fatal(logger, "invalid scenario", err)
+	}
+	if scenario.Seed == 0 {
+		scenario.Seed = uint64(time.Now().UnixNano())
 	}
-	numRandoms, numBads := *randomsPtr, *badsPtr
-
-	saveTo, loadFrom := *saveTohPtr, *loadFromhPtr
 
-	if *alphaPtr < 0 || *alphaPtr > 1 {
-		log.Fatalf("Bad behavior percentage must be between 0 and 1\n")
+	switch *formatPtr {
+	case "text", "json", "csv":
+	default:
+		fatal(logger, "invalid flags", errors.New("format must be one of text, json or csv"))
 	}
-	pkg.BadBehavior = *alphaPtr
 
-	return numTypes, runTime, numTraders, numRandoms, numBads, saveTo, loadFrom
+	return Options{
+		Scenario:     scenario,
+		SaveTo:       *saveTohPtr,
+		LoadFrom:     *loadFromhPtr,
+		CheckpointTo: *checkpointToPtr,
+		ResumeFrom:   *resumeFromPtr,
+		Journal:      *journalPtr,
+		Format:       *formatPtr,
+		HTTP:         *httpPtr,
+		Logging:      logging,
+	}
 }
 
 func main() {
-	logger := log.Default()
-	var system *internal.System
-	numTypes, runTime, numTraders, numRandoms, numBads, saveTo, loadFrom := ParseFlags()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "sweep":
+			runSweep(os.Args[2:])
+			return
+		case "replay":
+			runReplay(os.Args[2:])
+			return
+		case "grind":
+			runGrind(os.Args[2:])
+			return
+		case "node":
+			runNode(os.Args[2:])
+			return
+		case "cluster":
+			runCluster(os.Args[2:])
+			return
+		}
+	}
 
-	if loadFrom == "" {
-		finish := make(chan bool, 1)
-		system = internal.NewSystem()
+	var system *internal.System
+	var interrupted error
+	options := ParseFlags()
+	logger := internal.NewLogger(os.Stderr, options.Logging)
+	slog.SetDefault(logger)
+	scenario, saveTo := options.Scenario, options.SaveTo
 
-		logger.Printf("Starting simulation with %d types (alpha = %.2f%%)...\n", numTypes, pkg.BadBehavior*100)
-		if err := system.Init(numTraders, numRandoms, numBads, uint(numTypes)); err != nil {
-			logger.Fatalf("Error initializing system: %v\n", err)
+	if options.LoadFrom != "" {
+		s, err := internal.Load(options.LoadFrom)
+		if err != nil {
+			fatal(logger, "error loading system", err)
 		}
-		logger.Println("Simulation initialized!")
 
-		logger.Println("Starting simulation...")
-		done := make(chan bool, 1)
+		system = s
+		logger.Info("simulation loaded", "path", options.LoadFrom)
+	} else {
+		// The first interrupt stops the run, which is still drained and
+		// saved. A second one kills the process.
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 		go func() {
-			system.Start(finish)
-			done <- true
+			<-ctx.Done()
+			stop()
 		}()
-		logger.Println("Simulation started!")
 
-		logger.Printf("Waiting for %s...\n", runTime)
-		time.Sleep(runTime)
-		finish <- true
-		<-done
-		logger.Println("Simulation stopped!")
+		var journal *internal.Journal
+		if options.Journal != "" {
+			j, err := internal.OpenJournal(options.Journal, options.ResumeFrom != "")
+			if err != nil {
+				fatal(logger, "error opening journal", err)
+			}
+			journal = j
+		}
+
+		if options.ResumeFrom != "" {
+			s, err := internal.Resume(options.ResumeFrom)
+			if err != nil {
+				fatal(logger, "error resuming system", err)
+			}
+			logger.Info("simulation resumed", "path", options.ResumeFrom)
+
+			system = s
+			system.SetLogger(logger)
+			system.SetJournal(journal)
+			system.SetFaults(scenario.Faults)
+			system.SetGossip(scenario.Gossip)
+		} else {
+			system = scenario.System(logger, journal)
+			if err := scenario.Init(system); err != nil {
+				fatal(logger, "error initializing system", err)
+			}
+		}
+		if options.HTTP != "" {
+			serveHTTP(logger, options.HTTP, system)
+		}
+		interrupted = system.Run(ctx, scenario.RunTime(), scenario.SampleInterval())
+		if interrupted != nil {
+			logger.Warn("saving the interrupted simulation")
+		}
+
+		if journal != nil {
+			if err := journal.Close(); err != nil {
+				fatal(logger, "error closing journal", err)
+			}
+			logger.Info("journal saved", "path", options.Journal)
+		}
 
 		if err := system.Save(saveTo); err != nil {
-			logger.Fatalf("Error saving system: %v\n", err)
+			fatal(logger, "error saving system", err)
 		}
-		logger.Printf("System saved to %s\n", saveTo)
-	} else {
-		s, err := internal.Load(loadFrom)
-		if err != nil {
-			logger.Fatalf("Error loading system: %v\n", err)
+		logger.Info("system saved", "path", saveTo)
+
+		if scenario.Sample > 0 {
+			samplesPath := internal.SamplesPath(saveTo)
+			if err := system.SaveSamples(samplesPath); err != nil {
+				fatal(logger, "error saving samples", err)
+			}
+			logger.Info("samples saved", "path", samplesPath)
 		}
 
-		system = s
-		logger.Printf("Simulation loaded from %s\n", loadFrom)
+		if options.CheckpointTo != "" {
+			if err := system.SaveCheckpoint(options.CheckpointTo); err != nil {
+				fatal(logger, "error saving checkpoint", err)
+			}
+			logger.Info("checkpoint saved", "path", options.CheckpointTo)
+		}
 	}
 
-	internal.AnalyzeSystem(system)
+	if err := internal.WriteMetrics(os.Stdout, internal.Analyze(system), options.Format); err != nil {
+		fatal(logger, "error writing metrics", err)
+	}
+	if interrupted != nil {
+		os.Exit(130)
+	}
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Arka-Lab/LoR/internal"
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+func nodeFlags(name string, config *internal.DaemonConfig, logging *internal.LogOptions) *flag.FlagSet {
+	params := &config.Params
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.UintVar(&config.Types, "type", config.Types, "number of coin types")
+	flags.StringVar(&config.Behavior, "behavior", config.Behavior, "behavior of the trader")
+	flags.DurationVar(&config.Time, "time", config.Time, "how long to trade once every peer has joined")
+	flags.DurationVar(&config.Wait, "wait", config.Wait, "how long to wait for every peer to join")
+	flags.DurationVar(&config.Timeout, "vote-timeout", config.Timeout, "how long a submitter waits for the votes of its team")
+	flags.Float64Var(&params.BadBehavior, "alpha", params.BadBehavior, "bad behavior percentage")
+	flags.IntVar(&params.FractalMin, "fractal-min", params.FractalMin, "minimum number of cooperation rings in a fractal ring")
+	flags.IntVar(&params.FractalMax, "fractal-max", params.FractalMax, "maximum number of cooperation rings in a fractal ring")
+	flags.IntVar(&params.RoundLength, "round-length", params.RoundLength, "milliseconds between the coins of a trader")
+	flags.IntVar(&params.VerificationMin, "team-min", params.VerificationMin, "minimum verification team size")
+	flags.IntVar(&params.VerificationMax, "team-max", params.VerificationMax, "maximum verification team size")
+	flags.BoolVar(&params.BeaconTeams, "beacon-teams", params.BeaconTeams, "derive the first verification team member from a shared beacon")
+	flags.StringVar(&params.Scheme, "scheme", params.Scheme, "signature scheme of trader keys and coin IDs (rsa-pss or ed25519)")
+	flags.IntVar(&params.KeySize, "key-size", params.KeySize, "RSA key size in bits")
+	logFlags(flags, logging)
+	return flags
+}
+
+func defaultDaemonConfig() internal.DaemonConfig {
+	return internal.DaemonConfig{
+		Types:    3,
+		Behavior: pkg.BehaviorNormal,
+		Params:   pkg.DefaultParams(),
+		Timeout:  time.Second,
+		Wait:     30 * time.Second,
+		Time:     time.Minute,
+	}
+}
+
+func runNode(args []string) {
+	config := defaultDaemonConfig()
+	var logging internal.LogOptions
+	flags := nodeFlags("node", &config, &logging)
+	flags.StringVar(&config.Listen, "listen", "127.0.0.1:7000", "address to listen on for peers")
+	peersPtr := flags.String("peers", "", "comma-separated addresses of the other traders")
+	flags.Uint64Var(&config.Seed, "seed", 0, "random seed of the trader (0 picks one from the current time)")
+	flags.Parse(args)
+
+	if config.Seed == 0 {
+		config.Seed = uint64(time.Now().UnixNano())
+	}
+	for _, peer := range strings.Split(*peersPtr, ",") {
+		if peer = strings.TrimSpace(peer); peer != "" {
+			config.Peers = append(config.Peers, peer)
+		}
+	}
+	logger := internal.NewLogger(os.Stderr, logging)
+	config.Logger = logger
+
+	daemon, err := internal.NewDaemon(config)
+	if err != nil {
+		fatal(logger, "error creating trader", err)
+	}
+	logger.Info("trader listening", "trader_id", daemon.Trader().ID, "address", config.Listen)
+
+	stats, err := daemon.Run()
+	if err != nil {
+		fatal(logger, "error running trader", err)
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(stats); err != nil {
+		fatal(logger, "error writing stats", err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/Arka-Lab/LoR/internal"
+)
+
+func runReplay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	journalPtr := flags.String("journal", "system.journal.jsonl", "file path of the event journal to replay")
+	snapshotPtr := flags.String("snapshot", "system.json", "file path of the snapshot to check the replayed state against")
+	saveToPtr := flags.String("save-to", "", "file path to save the replayed system")
+	var logging internal.LogOptions
+	logFlags(flags, &logging)
+	flags.Parse(args)
+	logger := internal.NewLogger(os.Stderr, logging)
+
+	system, count, err := internal.ReplayFile(*journalPtr)
+	if err != nil {
+		fatal(logger, "error replaying journal", err)
+	}
+	logger.Info("journal replayed", "events", count, "path", *journalPtr)
+
+	if *saveToPtr != "" {
+		if err := system.Save(*saveToPtr); err != nil {
+			fatal(logger, "error saving system", err)
+		}
+		logger.Info("replayed system saved", "path", *saveToPtr)
+	}
+
+	if *snapshotPtr == "" {
+		return
+	}
+	expected, err := internal.Load(*snapshotPtr)
+	if err != nil {
+		fatal(logger, "error loading snapshot", err)
+	}
+
+	differences := internal.CompareSystems(expected, system)
+	if len(differences) == 0 {
+		logger.Info("replayed state matches", "path", *snapshotPtr)
+		return
+	}
+	for index, difference := range differences {
+		if index == 20 {
+			logger.Error("more differences", "count", len(differences)-index)
+			break
+		}
+		logger.Error("replayed state mismatch", "difference", difference)
+	}
+	os.Exit(1)
+}
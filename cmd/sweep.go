@@ -0,0 +1,110 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Arka-Lab/LoR/internal"
+)
+
+func runSweep(args []string) {
+	flags := flag.NewFlagSet("sweep", flag.ExitOnError)
+	specPtr := flags.String("spec", "sweeps/gamma.json", "file path of the JSON or YAML sweep spec")
+	outPtr := flags.String("out", "result", "directory to write snapshots, results and the manifest to")
+	workersPtr := flags.Int("workers", runtime.NumCPU(), "number of points to run in parallel")
+	cleanupPtr := flags.Bool("cleanup", false, "remove the output directory before running")
+	savePtr := flags.Bool("save", false, "archive results and snapshots as zip files after the sweep")
+	compressPtr := flags.Bool("compress", false, "write gzip-compressed snapshots (.json.gz)")
+	var logging internal.LogOptions
+	logFlags(flags, &logging)
+	flags.Parse(args)
+	logger := internal.NewLogger(os.Stderr, logging)
+
+	spec, err := internal.LoadSweepSpec(*specPtr)
+	if err != nil {
+		fatal(logger, "error loading sweep spec", err)
+	}
+	if *cleanupPtr {
+		if err := os.RemoveAll(*outPtr); err != nil {
+			fatal(logger, "error cleaning up "+*outPtr, err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	options := internal.SweepOptions{OutDir: *outPtr, Workers: *workersPtr, Compress: *compressPtr, Logging: logging}
+	manifest, err := internal.RunSweep(ctx, spec, options, logger)
+	if err != nil {
+		fatal(logger, "sweep stopped", err)
+	}
+
+	counts := make(map[string]int)
+	for _, status := range manifest.Points {
+		counts[status.Status]++
+	}
+	logger.Info("sweep finished", "done", counts[internal.PointDone], "failed", counts[internal.PointFailed], "skipped", counts[internal.PointSkipped])
+
+	if *savePtr {
+		base := strings.TrimSuffix(filepath.Clean(*outPtr), string(filepath.Separator))
+		if err := archive(*outPtr, ".result", base+"-output.zip"); err != nil {
+			fatal(logger, "error saving results", err)
+		}
+		logger.Info("output saved", "path", base+"-output.zip")
+		snapshotExt := ".json"
+		if *compressPtr {
+			snapshotExt = ".json.gz"
+		}
+		if err := archive(*outPtr, snapshotExt, base+"-backup.zip"); err != nil {
+			fatal(logger, "error saving snapshots", err)
+		}
+		logger.Info("backup saved", "path", base+"-backup.zip")
+	}
+}
+
+func archive(dir, ext, zipPath string) error {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+ext))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	folder := strings.TrimSuffix(filepath.Base(zipPath), ".zip")
+	writer := zip.NewWriter(file)
+	for _, match := range matches {
+		if filepath.Base(match) == "manifest.json" {
+			continue
+		}
+		if err := addToArchive(writer, match, folder+"/"+filepath.Base(match)); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+func addToArchive(writer *zip.Writer, filePath, name string) error {
+	source, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := writer.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(target, source)
+	return err
+}
@@ -6,6 +6,7 @@ require (
 	github.com/google/uuid v1.6.0
 	golang.org/x/crypto v0.27.0
 	golang.org/x/exp v0.0.0-20240909161429-701f63a606c0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require golang.org/x/sys v0.25.0 // indirect
@@ -1,22 +1,83 @@
 package internal
 
 import (
-	"fmt"
+	"slices"
 
 	"github.com/Arka-Lab/LoR/pkg"
 )
 
-func AnalyzeSystem(system *System) {
-	fmt.Println("Number of coins:", len(system.Coins))
-	fmt.Println("Number of fractal rings:", len(system.Fractals))
+type Metrics struct {
+	Coins              int   `json:"coins"`
+	Fractals           int   `json:"fractals"`
+	RunCoins           int   `json:"run_coins"`
+	SubmittedFractals  int   `json:"submitted_fractals"`
+	AverageSubmitted   Ratio `json:"average_submitted"`
+	AcceptRate         Ratio `json:"accept_rate"`
+	BadAcceptCount     int   `json:"bad_accept_count"`
+	BadRejectCount     int   `json:"bad_reject_count"`
+	CoinSatisfaction   Ratio `json:"coin_satisfaction"`
+	TraderSatisfaction Ratio `json:"trader_satisfaction"`
+	AverageAdjacency   Ratio `json:"average_adjacency"`
+	MaximumAdjacency   int   `json:"maximum_adjacency"`
+	MaximumRingCount   int   `json:"maximum_ring_count"`
+
+	CoalitionSize        int   `json:"coalition_size"`
+	CoalitionSubmitted   int   `json:"coalition_submitted"`
+	CoalitionAccepted    int   `json:"coalition_accepted"`
+	CoalitionBadAccepted int   `json:"coalition_bad_accept_count"`
+	CapturedTeams        int   `json:"captured_teams"`
+	CoalitionAcceptRate  Ratio `json:"coalition_accept_rate"`
+
+	Principals      []PrincipalMetrics `json:"principals,omitempty"`
+	MajorityWallets int                `json:"majority_wallets,omitempty"`
+
+	Messages           int   `json:"messages"`
+	Dropped            int   `json:"dropped"`
+	Duplicated         int   `json:"duplicated"`
+	StaleViews         int   `json:"stale_views"`
+	CheckCoinsFailures int   `json:"check_coins_failures"`
+	VoteTimeouts       int   `json:"vote_timeouts"`
+	MeanVerdictTime    Ratio `json:"mean_verdict_time"`
+	MaxVerdictTime     Ratio `json:"max_verdict_time"`
+
+	GossipFanout     int   `json:"gossip_fanout"`
+	Coverage         Ratio `json:"coverage"`
+	RedundantPerCoin Ratio `json:"redundant_per_coin"`
+	PulledCoins      int   `json:"pulled_coins"`
+	MeanPropagation  Ratio `json:"mean_propagation"`
+	MaxPropagation   Ratio `json:"max_propagation"`
+	PartialViewRings int   `json:"partial_view_rings"`
+	MeanViewAtRing   Ratio `json:"mean_view_at_ring"`
+
+	Topology       string `json:"topology"`
+	MeanDegree     Ratio  `json:"mean_degree"`
+	MaxDegree      int    `json:"max_degree"`
+	Clustering     Ratio  `json:"clustering"`
+	MeanPathLength Ratio  `json:"mean_path_length"`
+	OwnerDistance  Ratio  `json:"owner_distance"`
+
+	CoinRejections    ErrorSummary `json:"coin_rejections"`
+	VoteRejections    ErrorSummary `json:"vote_rejections"`
+	FractalRejections ErrorSummary `json:"fractal_rejections"`
+}
+
+func Analyze(system *System) Metrics {
+	metrics := Metrics{
+		Coins:             len(system.Coins),
+		Fractals:          len(system.Fractals),
+		SubmittedFractals: system.FractalCounter,
+		BadAcceptCount:    system.BadAcceptCount,
+		BadRejectCount:    system.BadRejectCount,
+		CoinRejections:    system.Rejections.Coins,
+		VoteRejections:    system.Rejections.Votes,
+		FractalRejections: system.Rejections.Fractals,
+	}
 
-	runCoins := 0
 	for _, coin := range system.Coins {
 		if coin.Status == pkg.Run {
-			runCoins++
+			metrics.RunCoins++
 		}
 	}
-	fmt.Println("Number of run coins:", runCoins)
 
 	numSubmitted, totalSubmitted, acceptRate := 0, 0, 0.0
 	for traderID := range system.Traders {
@@ -26,102 +87,225 @@ func AnalyzeSystem(system *System) {
 			acceptRate += float64(system.AcceptedCount[traderID]) / float64(system.SubmitCount[traderID])
 		}
 	}
-	fmt.Printf("Average number of submitted fractal rings per trader: %.2f\n", float64(totalSubmitted)/float64(numSubmitted))
-	fmt.Printf("Average fractal ring acceptance rate per trader: %.2f%%\n", acceptRate/float64(numSubmitted)*100)
-
-	fmt.Println("Number of invalid accepted fractal rings:", system.BadAcceptCount)
-	fmt.Println("Number of valid rejected fractal rings:", system.BadRejectCount)
+	metrics.AverageSubmitted = Ratio(float64(totalSubmitted) / float64(numSubmitted))
+	metrics.AcceptRate = Ratio(acceptRate / float64(numSubmitted))
 
 	if RunFractals {
-		coinsCount, coinsTotal := 0, 0.
-		coinsSatisfaction := make(map[string]float64)
-		for _, fractal := range system.Fractals {
-			for _, ring := range fractal.CooperationRings {
-				if ring.Rounds != -1 {
-					satisfaction := float64(ring.Rounds) / float64(pkg.RoundsCount)
-					if !ring.IsValid {
-						satisfaction *= -1
-					}
-
-					coinsCount += len(ring.CoinIDs)
-					coinsTotal += satisfaction * float64(len(ring.CoinIDs))
-					for _, coinID := range ring.CoinIDs {
-						coinsSatisfaction[coinID] = satisfaction
-					}
+		analyzeSatisfaction(system, &metrics)
+		analyzeAdjacency(system, &metrics)
+	}
+	analyzeCoalition(system, &metrics)
+	analyzePrincipals(system, &metrics)
+	analyzeNetwork(system, &metrics)
+	analyzeGossip(system, &metrics)
+	analyzeTopology(system, &metrics)
+	return metrics
+}
+
+// analyzeTopology describes the overlay and how far the coins of a fractal
+// ring are from its submitter in it.
+func analyzeTopology(system *System, metrics *Metrics) {
+	metrics.Topology = TopologyComplete
+	if system.Overlay == nil {
+		return
+	}
+	metrics.Topology = system.topology.Kind
+
+	degrees, clustering := 0, 0.0
+	for _, traderID := range sortedKeys(system.Overlay) {
+		neighbors := system.Overlay[traderID]
+		degrees += len(neighbors)
+		metrics.MaxDegree = max(metrics.MaxDegree, len(neighbors))
+		if len(neighbors) < 2 {
+			continue
+		}
+		links := 0
+		for i, a := range neighbors {
+			for _, b := range neighbors[i+1:] {
+				if _, ok := slices.BinarySearch(system.Overlay[a], b); ok {
+					links++
 				}
 			}
 		}
-		fmt.Printf("Average satisfaction per coin: %.2f%%\n", float64(coinsTotal)/float64(coinsCount)*100)
+		clustering += float64(links) / float64(len(neighbors)*(len(neighbors)-1)/2)
+	}
+	metrics.MeanDegree = Ratio(float64(degrees) / float64(len(system.Overlay)))
+	metrics.Clustering = Ratio(clustering / float64(len(system.Overlay)))
 
-		traderSatisfaction := make(map[string][]float64)
-		for coinID, satisfaction := range coinsSatisfaction {
-			owner := system.Coins[coinID].Owner
-			traderSatisfaction[owner] = append(traderSatisfaction[owner], satisfaction)
+	distances := make(map[string]map[string]int, len(system.Overlay))
+	paths, pathLength := 0, 0
+	for traderID := range system.Overlay {
+		distances[traderID] = overlayDistances(system.Overlay, traderID)
+		for _, distance := range distances[traderID] {
+			if distance > 0 {
+				paths++
+				pathLength += distance
+			}
 		}
+	}
+	metrics.MeanPathLength = Ratio(float64(pathLength) / float64(paths))
 
-		tradersTotal := 0.
-		for _, satisfactions := range traderSatisfaction {
-			total := 0.
-			for _, satisfaction := range satisfactions {
-				total += satisfaction
+	owners, ownerDistance := 0, 0
+	for _, fractal := range system.Fractals {
+		for _, ring := range fractal.CooperationRings {
+			for _, coinID := range ring.CoinIDs {
+				if distance, ok := distances[fractal.Submitter][system.Coins[coinID].Owner]; ok {
+					owners++
+					ownerDistance += distance
+				}
 			}
-			tradersTotal += total / float64(len(satisfactions))
 		}
-		fmt.Printf("Average satisfaction per trader: %.2f%%\n", float64(tradersTotal)/float64(len(traderSatisfaction))*100)
+	}
+	metrics.OwnerDistance = Ratio(float64(ownerDistance) / float64(owners))
+}
 
-		hasFractal := make(map[string]map[string]bool)
-		communicationCount := make(map[string]int)
-		for traderID := range system.Traders {
-			hasFractal[traderID] = make(map[string]bool)
-			communicationCount[traderID] = 0
+func overlayDistances(overlay map[string][]string, source string) map[string]int {
+	distances := map[string]int{source: 0}
+	for queue := []string{source}; len(queue) > 0; queue = queue[1:] {
+		for _, neighbor := range overlay[queue[0]] {
+			if _, ok := distances[neighbor]; !ok {
+				distances[neighbor] = distances[queue[0]] + 1
+				queue = append(queue, neighbor)
+			}
 		}
-		for _, fractal := range system.Fractals {
-			for _, ring := range fractal.CooperationRings {
-				for _, coinID := range ring.CoinIDs {
-					owner := system.Coins[coinID].Owner
-					hasFractal[owner][fractal.ID] = true
-					communicationCount[owner] += len(ring.CoinIDs)
-					communicationCount[owner] += len(fractal.VerificationTeam)
-				}
+	}
+	return distances
+}
 
-				for _, traderID := range fractal.VerificationTeam {
-					communicationCount[traderID] += len(ring.CoinIDs)
-				}
+func analyzeGossip(system *System, metrics *Metrics) {
+	stats := system.Gossip
+	metrics.GossipFanout, metrics.PulledCoins, metrics.PartialViewRings = stats.Fanout, stats.Pulled, stats.Partial
+	metrics.Coverage = Ratio(float64(stats.Receipts) / float64(stats.Coins*(len(system.Traders)-1)))
+	metrics.RedundantPerCoin = Ratio(float64(stats.Redundant) / float64(stats.Coins))
+	metrics.MeanPropagation = Ratio(stats.Delay.Seconds() / float64(stats.Receipts))
+	metrics.MaxPropagation = Ratio(stats.MaxDelay.Seconds())
+	metrics.MeanViewAtRing = Ratio(stats.Views / float64(stats.Rings))
+}
+
+func analyzeNetwork(system *System, metrics *Metrics) {
+	stats := system.Network
+	metrics.Messages, metrics.Dropped, metrics.Duplicated = stats.Messages, stats.Dropped, stats.Duplicated
+	metrics.StaleViews, metrics.CheckCoinsFailures, metrics.VoteTimeouts = stats.StaleViews, stats.CheckCoinsFailures, stats.VoteTimeouts
+	metrics.MeanVerdictTime = Ratio(stats.VerdictTime.Seconds() / float64(stats.Verdicts))
+	metrics.MaxVerdictTime = Ratio(stats.MaxVerdictTime.Seconds())
+}
+
+func analyzeCoalition(system *System, metrics *Metrics) {
+	members := make(map[string]bool)
+	for traderID, behavior := range system.Behaviors {
+		if base, _ := pkg.SplitBehavior(behavior); base == pkg.BehaviorCoalition || base == pkg.BehaviorGrinder {
+			members[traderID] = true
+			metrics.CoalitionSubmitted += system.SubmitCount[traderID]
+			metrics.CoalitionAccepted += system.AcceptedCount[traderID]
+		}
+	}
+	metrics.CoalitionSize = len(members)
+	metrics.CoalitionAcceptRate = Ratio(float64(metrics.CoalitionAccepted) / float64(metrics.CoalitionSubmitted))
+
+	for _, fractal := range system.Fractals {
+		if members[fractal.Submitter] && !fractal.IsValid {
+			metrics.CoalitionBadAccepted++
+		}
+
+		captured := 0
+		for _, traderID := range fractal.VerificationTeam {
+			if members[traderID] {
+				captured++
 			}
 		}
+		if 2*captured > len(fractal.VerificationTeam) {
+			metrics.CapturedTeams++
+		}
+	}
+}
 
-		tradersCount := 0
-		totalAdjacency, maximumAdjacency := 0, 0
-		for traderID := range system.Traders {
-			if communicationCount[traderID] > 0 {
-				tradersCount++
-				totalAdjacency += communicationCount[traderID]
-				if communicationCount[traderID] > maximumAdjacency {
-					maximumAdjacency = communicationCount[traderID]
+func analyzeSatisfaction(system *System, metrics *Metrics) {
+	coinsCount, coinsTotal := 0, 0.
+	coinsSatisfaction := make(map[string]float64)
+	for _, fractal := range system.Fractals {
+		for _, ring := range fractal.CooperationRings {
+			if ring.Rounds != -1 {
+				satisfaction := float64(ring.Rounds) / float64(system.Params.RoundsCount)
+				if !ring.IsValid {
+					satisfaction *= -1
+				}
+
+				coinsCount += len(ring.CoinIDs)
+				coinsTotal += satisfaction * float64(len(ring.CoinIDs))
+				for _, coinID := range ring.CoinIDs {
+					coinsSatisfaction[coinID] = satisfaction
 				}
 			}
 		}
-		fmt.Printf("Average adjacency per trader: %.2f\n", float64(totalAdjacency)/float64(tradersCount))
-		fmt.Println("Maximum adjacency per trader:", maximumAdjacency)
+	}
+	metrics.CoinSatisfaction = Ratio(float64(coinsTotal) / float64(coinsCount))
+
+	traderSatisfaction := make(map[string][]float64)
+	for coinID, satisfaction := range coinsSatisfaction {
+		owner := system.Coins[coinID].Owner
+		traderSatisfaction[owner] = append(traderSatisfaction[owner], satisfaction)
+	}
 
-		ringCount := make(map[string]int)
-		for traderID := range system.Traders {
-			ringCount[traderID] = 0
+	tradersTotal := 0.
+	for _, satisfactions := range traderSatisfaction {
+		total := 0.
+		for _, satisfaction := range satisfactions {
+			total += satisfaction
 		}
-		for _, fractal := range system.Fractals {
-			for _, ring := range fractal.CooperationRings {
-				for _, coinID := range ring.CoinIDs {
-					coin := system.Coins[coinID]
-					ringCount[coin.Owner]++
-				}
+		tradersTotal += total / float64(len(satisfactions))
+	}
+	metrics.TraderSatisfaction = Ratio(float64(tradersTotal) / float64(len(traderSatisfaction)))
+}
+
+func analyzeAdjacency(system *System, metrics *Metrics) {
+	hasFractal := make(map[string]map[string]bool)
+	communicationCount := make(map[string]int)
+	for traderID := range system.Traders {
+		hasFractal[traderID] = make(map[string]bool)
+		communicationCount[traderID] = 0
+	}
+	for _, fractal := range system.Fractals {
+		for _, ring := range fractal.CooperationRings {
+			for _, coinID := range ring.CoinIDs {
+				owner := system.Coins[coinID].Owner
+				hasFractal[owner][fractal.ID] = true
+				communicationCount[owner] += len(ring.CoinIDs)
+				communicationCount[owner] += len(fractal.VerificationTeam)
+			}
+
+			for _, traderID := range fractal.VerificationTeam {
+				communicationCount[traderID] += len(ring.CoinIDs)
+			}
+		}
+	}
+
+	tradersCount, totalAdjacency := 0, 0
+	for traderID := range system.Traders {
+		if communicationCount[traderID] > 0 {
+			tradersCount++
+			totalAdjacency += communicationCount[traderID]
+			if communicationCount[traderID] > metrics.MaximumAdjacency {
+				metrics.MaximumAdjacency = communicationCount[traderID]
 			}
 		}
-		maxRings := 0
-		for _, count := range ringCount {
-			if count > maxRings {
-				maxRings = count
+	}
+	metrics.AverageAdjacency = Ratio(float64(totalAdjacency) / float64(tradersCount))
+
+	ringCount := make(map[string]int)
+	for traderID := range system.Traders {
+		ringCount[traderID] = 0
+	}
+	for _, fractal := range system.Fractals {
+		for _, ring := range fractal.CooperationRings {
+			for _, coinID := range ring.CoinIDs {
+				coin := system.Coins[coinID]
+				ringCount[coin.Owner]++
 			}
 		}
-		fmt.Println("Maximum cooperation ring count:", maxRings)
+	}
+	for _, count := range ringCount {
+		if count > metrics.MaximumRingCount {
+			metrics.MaximumRingCount = count
+		}
 	}
 }
@@ -0,0 +1,72 @@
+package internal
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+	"golang.org/x/exp/maps"
+)
+
+type Checkpoint struct {
+	System  *System                    `json:"system"`
+	Clock   time.Duration              `json:"clock"`
+	Random  []byte                     `json:"random"`
+	Retired []string                   `json:"retired"`
+	Traders map[string]pkg.TraderState `json:"traders"`
+}
+
+func (system *System) Checkpoint() (*Checkpoint, error) {
+	random, err := system.random.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	retired := maps.Keys(system.retired)
+	slices.Sort(retired)
+	checkpoint := &Checkpoint{
+		System:  system,
+		Clock:   system.clock.Now(),
+		Random:  random,
+		Retired: retired,
+		Traders: make(map[string]pkg.TraderState, len(system.Traders)),
+	}
+	for traderID, trader := range system.Traders {
+		state, err := trader.State()
+		if err != nil {
+			return nil, err
+		}
+		checkpoint.Traders[traderID] = state
+	}
+	return checkpoint, nil
+}
+
+func (checkpoint *Checkpoint) Restore() (*System, error) {
+	system := checkpoint.System
+	random, err := tools.RestoreRandom(checkpoint.Random)
+	if err != nil {
+		return nil, err
+	}
+	system.random = random
+	system.clock = tools.NewScheduler(checkpoint.Clock)
+
+	for _, traderID := range checkpoint.Retired {
+		system.retired[traderID] = true
+	}
+	for traderID, trader := range system.Traders {
+		state, ok := checkpoint.Traders[traderID]
+		if !ok {
+			return nil, fmt.Errorf("checkpoint has no state for trader %s", traderID)
+		} else if err := trader.Restore(state, system.society); err != nil {
+			return nil, err
+		}
+		if state.BanUntil > 0 {
+			system.bans[traderID] = state.BanUntil
+		}
+	}
+	system.joinBehaviors()
+	system.applyOverlay()
+	return system, nil
+}
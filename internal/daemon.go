@@ -0,0 +1,378 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+	"github.com/google/uuid"
+)
+
+const (
+	dialInterval = 100 * time.Millisecond
+	outboxSize   = 4096
+)
+
+// DaemonConfig describes one trader running as its own process. Every daemon
+// of a cluster must use the same Types and Params, and name its peers by the
+// exact addresses they listen on.
+type DaemonConfig struct {
+	Listen   string
+	Peers    []string
+	Seed     uint64
+	Types    uint
+	Behavior string
+	Params   pkg.Params
+	Timeout  time.Duration
+	Wait     time.Duration
+	Time     time.Duration
+	Logger   *slog.Logger
+}
+
+type DaemonStats struct {
+	TraderID  string `json:"trader_id"`
+	Traders   int    `json:"traders"`
+	Minted    int    `json:"minted"`
+	Coins     int    `json:"coins"`
+	Submitted int    `json:"submitted"`
+	Accepted  int    `json:"accepted"`
+	Fractals  int    `json:"fractals"`
+	Votes     int    `json:"votes"`
+	Errors    int    `json:"errors"`
+	Dropped   int    `json:"dropped"`
+}
+
+// Daemon runs one trader outside the simulator. Without a ledger, the
+// submitter of a fractal ring announces the verdict of its team itself, and
+// settlement rounds and bans are left to the simulator.
+type Daemon struct {
+	config    DaemonConfig
+	trader    *pkg.Trader
+	logger    *slog.Logger
+	inbox     chan Message
+	local     []Message
+	addresses map[string]string
+	outboxes  map[string]chan Message
+	tallies   map[string]*tally
+	stats     DaemonStats
+	listener  net.Listener
+	conns     sync.WaitGroup
+	closed    chan struct{}
+}
+
+func NewDaemon(config DaemonConfig) (*Daemon, error) {
+	if err := config.Params.Validate(); err != nil {
+		return nil, err
+	} else if config.Types < 1 {
+		return nil, errors.New("number of types must be positive")
+	}
+
+	random := tools.NewRandom(config.Seed)
+	behavior, err := pkg.NewBehavior(config.Behavior, pkg.NewSociety())
+	if err != nil {
+		return nil, err
+	}
+	amount := random.Float64() * 1000
+	wallet, err := uuid.NewRandomFromReader(random)
+	if err != nil {
+		return nil, err
+	}
+	trader := pkg.CreateTrader(config.Params, behavior, amount, wallet.String(), config.Types, random.Child())
+	if trader == nil {
+		return nil, errors.New("failed to create trader")
+	} else if err := trader.SaveTrader(*trader); err != nil {
+		return nil, err
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Daemon{
+		config:    config,
+		trader:    trader,
+		logger:    logger.With("component", ComponentTrader, "trader_id", trader.ID),
+		inbox:     make(chan Message, outboxSize),
+		addresses: make(map[string]string),
+		outboxes:  make(map[string]chan Message),
+		tallies:   make(map[string]*tally),
+		stats:     DaemonStats{TraderID: trader.ID},
+		closed:    make(chan struct{}),
+	}, nil
+}
+
+func (daemon *Daemon) Trader() *pkg.Trader {
+	return daemon.trader
+}
+
+// Run listens for peers, waits until it knows every trader of the cluster,
+// trades for config.Time and returns what the trader saw.
+func (daemon *Daemon) Run() (DaemonStats, error) {
+	listener, err := net.Listen("tcp", daemon.config.Listen)
+	if err != nil {
+		return daemon.stats, err
+	}
+	daemon.listener = listener
+	go daemon.accept()
+	defer daemon.close()
+
+	for _, address := range daemon.config.Peers {
+		outbox := make(chan Message, outboxSize)
+		daemon.outboxes[address] = outbox
+		go daemon.dial(address, outbox)
+	}
+
+	deadline := time.After(daemon.config.Wait)
+	for len(daemon.trader.Data.Traders) <= len(daemon.config.Peers) {
+		select {
+		case message := <-daemon.inbox:
+			daemon.handle(message)
+		case <-deadline:
+			return daemon.stats, fmt.Errorf("only %d of %d traders joined", len(daemon.trader.Data.Traders), len(daemon.config.Peers)+1)
+		}
+	}
+	daemon.logger.Info("joined the cluster", "traders", len(daemon.trader.Data.Traders))
+
+	mint := time.NewTicker(daemon.config.Params.RoundDuration())
+	defer mint.Stop()
+	stop := time.After(daemon.config.Time)
+	for {
+		select {
+		case message := <-daemon.inbox:
+			daemon.handle(message)
+		case <-mint.C:
+			daemon.mint()
+		case <-stop:
+			daemon.stats.Traders = len(daemon.trader.Data.Traders)
+			daemon.stats.Coins = len(daemon.trader.Data.Coins)
+			return daemon.stats, nil
+		}
+		for len(daemon.local) > 0 {
+			message := daemon.local[0]
+			daemon.local = daemon.local[1:]
+			daemon.handle(message)
+		}
+	}
+}
+
+func (daemon *Daemon) accept() {
+	for {
+		conn, err := daemon.listener.Accept()
+		if err != nil {
+			return
+		}
+		daemon.conns.Add(1)
+		go func() {
+			defer daemon.conns.Done()
+			defer conn.Close()
+			go func() {
+				<-daemon.closed
+				conn.Close()
+			}()
+
+			reader := bufio.NewReader(conn)
+			for {
+				message, err := ReadFrame(reader)
+				if err != nil {
+					return
+				}
+				select {
+				case daemon.inbox <- message:
+				case <-daemon.closed:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// dial connects to a peer until it answers, introduces the trader and then
+// writes the outbox to it.
+func (daemon *Daemon) dial(address string, outbox chan Message) {
+	var conn net.Conn
+	for conn == nil {
+		c, err := net.DialTimeout("tcp", address, daemon.config.Wait)
+		if err == nil {
+			conn = c
+			break
+		}
+		select {
+		case <-time.After(dialInterval):
+		case <-daemon.closed:
+			return
+		}
+	}
+	defer conn.Close()
+
+	writer := bufio.NewWriter(conn)
+	hello := Hello{Trader: *daemon.trader, Address: daemon.config.Listen, Types: daemon.config.Types, Params: daemon.config.Params}
+	hello.Trader.Data = nil
+	if err := WriteFrame(writer, Message{From: daemon.trader.ID, Body: hello}); err != nil {
+		return
+	}
+	for {
+		if err := writer.Flush(); err != nil {
+			return
+		}
+		select {
+		case message := <-outbox:
+			if err := WriteFrame(writer, message); err != nil {
+				return
+			}
+			for len(outbox) > 0 {
+				if err := WriteFrame(writer, <-outbox); err != nil {
+					return
+				}
+			}
+		case <-daemon.closed:
+			return
+		}
+	}
+}
+
+func (daemon *Daemon) close() {
+	close(daemon.closed)
+	daemon.listener.Close()
+	daemon.conns.Wait()
+}
+
+func (daemon *Daemon) send(to string, body Body) {
+	message := Message{From: daemon.trader.ID, To: to, Body: body}
+	if to == daemon.trader.ID {
+		daemon.local = append(daemon.local, message)
+		return
+	}
+
+	outbox, ok := daemon.outboxes[daemon.addresses[to]]
+	if !ok {
+		daemon.stats.Dropped++
+		return
+	}
+	select {
+	case outbox <- message:
+	default:
+		daemon.stats.Dropped++
+	}
+}
+
+func (daemon *Daemon) broadcast(body Body) {
+	for traderID := range daemon.trader.Data.Traders {
+		daemon.send(traderID, body)
+	}
+}
+
+func (daemon *Daemon) handle(message Message) {
+	switch body := message.Body.(type) {
+	case Hello:
+		daemon.join(body)
+	case CoinAnnouncement:
+		if err := daemon.trader.SaveCoin(body.Coin); err != nil {
+			daemon.fail(err)
+			return
+		}
+		daemon.checkRings()
+	case FractalProposal:
+		vote := VerificationVote{FractalID: body.Fractal.ID, Accepted: true}
+		if err := daemon.trader.SubmitRing(&body.Fractal); err != nil {
+			vote.Accepted, vote.Reason = false, pkg.Reason(err)
+		}
+		daemon.send(message.From, vote)
+	case VerificationVote:
+		daemon.countVote(message.From, body)
+	case VoteTimeout:
+		if tally, ok := daemon.tallies[body.FractalID]; ok && tally.open {
+			daemon.closeVerification(tally)
+		}
+	case AcceptedFractal:
+		if err := daemon.trader.InformFractalRing(body.Fractal); err != nil {
+			daemon.fail(err)
+			return
+		}
+		daemon.stats.Fractals++
+	}
+}
+
+func (daemon *Daemon) join(hello Hello) {
+	if hello.Types != daemon.config.Types || hello.Params != daemon.config.Params {
+		daemon.fail(fmt.Errorf("trader %s runs with different parameters", hello.Trader.ID))
+		return
+	} else if _, ok := daemon.trader.Data.Traders[hello.Trader.ID]; ok {
+		return
+	} else if err := daemon.trader.SaveTrader(hello.Trader); err != nil {
+		daemon.fail(err)
+		return
+	}
+	daemon.addresses[hello.Trader.ID] = hello.Address
+}
+
+func (daemon *Daemon) mint() {
+	trader := daemon.trader
+	amount := trader.Data.Random.Float64() * 10
+	coinType := trader.Data.Random.IntN(int(trader.Data.CoinTypeCount))
+	coin := trader.CreateCoin(amount, uint(coinType))
+	if coin == nil {
+		return
+	} else if err := trader.SaveCoin(*coin); err != nil {
+		daemon.fail(err)
+		return
+	}
+	daemon.stats.Minted++
+	for traderID := range trader.Data.Traders {
+		if traderID != trader.ID {
+			daemon.send(traderID, CoinAnnouncement{Coin: *coin})
+		}
+	}
+	daemon.checkRings()
+}
+
+func (daemon *Daemon) checkRings() {
+	_, fractal := daemon.trader.CheckForRings(0)
+	if fractal == nil {
+		return
+	}
+
+	daemon.stats.Submitted++
+	daemon.tallies[fractal.ID] = &tally{fractal: *fractal, round: -1, open: true, votes: make(map[string]bool)}
+	for _, traderID := range fractal.VerificationTeam {
+		daemon.send(traderID, FractalProposal{Fractal: *fractal})
+	}
+	time.AfterFunc(daemon.config.Timeout, func() {
+		select {
+		case daemon.inbox <- Message{From: daemon.trader.ID, Body: VoteTimeout{FractalID: fractal.ID, Round: -1}}:
+		case <-daemon.closed:
+		}
+	})
+}
+
+func (daemon *Daemon) countVote(voter string, vote VerificationVote) {
+	tally, ok := daemon.tallies[vote.FractalID]
+	if !ok || !tally.open {
+		return
+	}
+	daemon.stats.Votes++
+	tally.votes[voter] = vote.Accepted
+	if len(tally.votes) == len(tally.fractal.VerificationTeam) {
+		daemon.closeVerification(tally)
+	}
+}
+
+func (daemon *Daemon) closeVerification(tally *tally) {
+	delete(daemon.tallies, tally.fractal.ID)
+	if rejectedBy(split(tally.fractal.VerificationTeam, tally.votes)) {
+		daemon.trader.RemoveFractalRing(tally.fractal.ID)
+		return
+	}
+	daemon.stats.Accepted++
+	daemon.broadcast(AcceptedFractal{Fractal: tally.fractal})
+}
+
+func (daemon *Daemon) fail(err error) {
+	daemon.stats.Errors++
+	daemon.logger.Debug("protocol error", "error", err)
+}
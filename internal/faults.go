@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+// Partition cuts a random Fraction of the traders off from the rest of the
+// network, ledger included, for Duration milliseconds starting Start
+// milliseconds into a run.
+type Partition struct {
+	Start    int     `json:"start"`
+	Duration int     `json:"duration"`
+	Fraction float64 `json:"fraction"`
+}
+
+type Partitions []Partition
+
+// Faults describes how the network mistreats messages between two different
+// nodes. Every link has its own latency, drawn once between half and one and
+// a half times Latency, and every message waits up to Jitter more. A
+// reordered message is held back by up to its delay again. Times are in
+// milliseconds.
+type Faults struct {
+	Latency    int        `json:"latency,omitempty"`
+	Jitter     int        `json:"jitter,omitempty"`
+	Drop       float64    `json:"drop,omitempty"`
+	Duplicate  float64    `json:"duplicate,omitempty"`
+	Reorder    float64    `json:"reorder,omitempty"`
+	Timeout    int        `json:"timeout,omitempty"`
+	Partitions Partitions `json:"partitions,omitempty"`
+}
+
+type NetworkStats struct {
+	Messages           int           `json:"messages"`
+	Dropped            int           `json:"dropped"`
+	Duplicated         int           `json:"duplicated"`
+	StaleViews         int           `json:"stale_views"`
+	CheckCoinsFailures int           `json:"check_coins_failures"`
+	VoteTimeouts       int           `json:"vote_timeouts"`
+	Verdicts           int           `json:"verdicts"`
+	VerdictTime        time.Duration `json:"verdict_time"`
+	MaxVerdictTime     time.Duration `json:"max_verdict_time"`
+}
+
+func (partitions *Partitions) String() string {
+	if partitions == nil {
+		return ""
+	}
+	parts := make([]string, len(*partitions))
+	for i, partition := range *partitions {
+		parts[i] = fmt.Sprintf("%d:%d:%v", partition.Start, partition.Duration, partition.Fraction)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (partitions *Partitions) Set(value string) error {
+	*partitions = nil
+	for _, part := range strings.Split(value, ",") {
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) != 3 {
+			return fmt.Errorf("partition %s must be start:duration:fraction", part)
+		}
+		start, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return err
+		}
+		duration, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return err
+		}
+		fraction, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return err
+		}
+		*partitions = append(*partitions, Partition{Start: start, Duration: duration, Fraction: fraction})
+	}
+	return nil
+}
+
+func (faults Faults) Validate() error {
+	if faults.Latency < 0 || faults.Jitter < 0 || faults.Timeout < 0 {
+		return errors.New("latency, jitter and vote timeout must be non-negative")
+	} else if faults.Reorder > 0 && faults.Latency == 0 && faults.Jitter == 0 {
+		return errors.New("reorder needs a latency or jitter to hold messages back by")
+	}
+	for _, p := range []float64{faults.Drop, faults.Duplicate, faults.Reorder} {
+		if p < 0 || p > 1 {
+			return errors.New("drop, duplicate and reorder probabilities must be between 0 and 1")
+		}
+	}
+	for _, partition := range faults.Partitions {
+		if partition.Start < 0 || partition.Duration <= 0 {
+			return errors.New("partition start must be non-negative and its duration positive")
+		} else if partition.Fraction <= 0 || partition.Fraction >= 1 {
+			return errors.New("partition fraction must be between 0 and 1")
+		}
+	}
+	return nil
+}
+
+func (faults Faults) Lossless() bool {
+	return faults.Latency == 0 && faults.Jitter == 0 && faults.Drop == 0 && faults.Duplicate == 0 && faults.Reorder == 0 && len(faults.Partitions) == 0
+}
+
+// delay draws how long a message from one node to another is in flight.
+func (faults Faults) delay(random *tools.Random, from, to string) time.Duration {
+	drbg := tools.NewDRBG(from + ">" + to)
+	link := float64(faults.Latency) * (0.5 + float64(drbg.Uint64()>>11)/(1<<53))
+	delay := time.Duration(link * float64(time.Millisecond))
+	if faults.Jitter > 0 {
+		delay += time.Duration(random.Int64N(int64(faults.Jitter) * int64(time.Millisecond)))
+	}
+	if faults.Reorder > 0 && random.Float64() < faults.Reorder && delay > 0 {
+		delay += time.Duration(random.Int64N(int64(delay)))
+	}
+	return delay
+}
+
+// roundDelay is the wait before each settlement round of an accepted fractal
+// ring. Without faults every ballot arrives at once, so the rounds run right
+// away as they always did. With faults a round lasts a round length, so that
+// late ballots and timeouts stay within it.
+func (system *System) roundDelay() time.Duration {
+	if system.faults.Lossless() {
+		return 0
+	}
+	return system.Params.RoundDuration()
+}
+
+func (system *System) voteTimeout() time.Duration {
+	if system.faults.Timeout > 0 {
+		return time.Duration(system.faults.Timeout) * time.Millisecond
+	}
+	return system.Params.RoundDuration() / 2
+}
+
+func (system *System) SetFaults(faults Faults) {
+	system.faults = faults
+}
+
+// schedulePartitions cuts and heals the network at the partition times,
+// counted from now.
+func (system *System) schedulePartitions(network *LocalNetwork) {
+	for i, partition := range system.faults.Partitions {
+		group, start := i+1, time.Duration(partition.Start)*time.Millisecond
+		system.clock.After(start, func() {
+			traderIDs := system.traderIDs()
+			system.random.Shuffle(len(traderIDs), func(i, j int) {
+				traderIDs[i], traderIDs[j] = traderIDs[j], traderIDs[i]
+			})
+			cut := traderIDs[:int(partition.Fraction*float64(len(traderIDs)))]
+			network.Cut(group, cut)
+			system.log(ComponentNetwork).Info("network partitioned", "partition", group, "cut_off", len(cut), "at", system.clock.Now())
+
+			system.clock.After(time.Duration(partition.Duration)*time.Millisecond, func() {
+				network.Heal(group, cut)
+				system.log(ComponentNetwork).Info("network partition healed", "partition", group, "at", system.clock.Now())
+			})
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package internal
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Gossip spreads coin announcements by pushing every coin a trader hears of
+// for the first time to Fanout random peers, until it has been passed on TTL
+// times. Every Pull milliseconds a trader also asks a random peer for the
+// coins it has missed. A zero Fanout sends every coin to every trader, or
+// floods it through the overlay in a partial-view topology.
+type Gossip struct {
+	Fanout int `json:"fanout,omitempty"`
+	TTL    int `json:"ttl,omitempty"`
+	Pull   int `json:"pull,omitempty"`
+}
+
+type GossipStats struct {
+	Fanout    int           `json:"fanout"`
+	Coins     int           `json:"coins"`
+	Receipts  int           `json:"receipts"`
+	Redundant int           `json:"redundant"`
+	Pulled    int           `json:"pulled"`
+	Delay     time.Duration `json:"delay"`
+	MaxDelay  time.Duration `json:"max_delay"`
+	Rings     int           `json:"rings"`
+	Partial   int           `json:"partial"`
+	Views     float64       `json:"views"`
+}
+
+func (gossip Gossip) Validate() error {
+	if gossip.Fanout < 0 || gossip.TTL < 0 || gossip.Pull < 0 {
+		return errors.New("gossip fanout, ttl and pull interval must be non-negative")
+	} else if gossip.Fanout > 0 && gossip.TTL < 1 {
+		return errors.New("gossip ttl must be positive when fanout is set")
+	}
+	return nil
+}
+
+func (system *System) SetGossip(gossip Gossip) {
+	system.gossip = gossip
+}
+
+// announce sends a coin the trader minted to the ledger and starts spreading
+// it to the other traders.
+func (node *Node) announce(announcement CoinAnnouncement) {
+	node.seen[announcement.Coin.ID] = announcement
+	node.countGossip(func(stats *GossipStats) { stats.Coins++ })
+	if !node.gossiping() {
+		node.broadcast(announcement)
+		return
+	}
+	node.send(LedgerID, announcement)
+	announcement.TTL = node.gossip.TTL
+	if node.gossip.Fanout == 0 {
+		announcement.TTL = node.traders
+	}
+	node.push(announcement, "")
+}
+
+func (node *Node) gossiping() bool {
+	return node.gossip.Fanout > 0 || node.trader.Data.Neighbors != nil
+}
+
+func (node *Node) countGossip(update func(stats *GossipStats)) {
+	node.later(func() { update(&node.system.Gossip) })
+}
+
+func (node *Node) push(announcement CoinAnnouncement, from string) {
+	peers := node.peers(from)
+	node.trader.Data.Random.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+	fanout := node.gossip.Fanout
+	if fanout == 0 {
+		fanout = len(peers)
+	}
+	for _, traderID := range peers[:min(fanout, len(peers))] {
+		node.send(traderID, announcement)
+	}
+}
+
+// peers returns the traders the node gossips with: its neighbors, or every
+// trader it knows without a topology.
+func (node *Node) peers(except string) []string {
+	known := node.trader.Data.Neighbors
+	if known == nil {
+		known = sortedKeys(node.trader.Data.Traders)
+	}
+	peers := make([]string, 0, len(known))
+	for _, traderID := range known {
+		if traderID != node.trader.ID && traderID != except {
+			peers = append(peers, traderID)
+		}
+	}
+	return peers
+}
+
+// receiveCoin saves a coin the first time the trader hears of it and passes
+// it on while its TTL lasts.
+func (node *Node) receiveCoin(from string, announcement CoinAnnouncement) {
+	if _, ok := node.seen[announcement.Coin.ID]; ok {
+		node.countGossip(func(stats *GossipStats) { stats.Redundant++ })
+		return
+	}
+	node.seen[announcement.Coin.ID] = announcement
+
+	delay := node.now - announcement.Created
+	node.countGossip(func(stats *GossipStats) {
+		stats.Receipts++
+		stats.Delay += delay
+		stats.MaxDelay = max(stats.MaxDelay, delay)
+	})
+
+	node.learnOwner(announcement)
+	node.saveCoin(announcement.Coin)
+	if announcement.TTL--; node.gossiping() && announcement.TTL > 0 {
+		node.push(announcement, from)
+	}
+}
+
+// learnOwner stores the public record of a coin owner the trader has not met,
+// which only happens outside the complete topology.
+func (node *Node) learnOwner(announcement CoinAnnouncement) {
+	if announcement.Owner == nil {
+		return
+	} else if _, ok := node.trader.Data.Traders[announcement.Owner.ID]; ok {
+		return
+	} else if err := node.trader.SaveTrader(*announcement.Owner); err != nil {
+		node.reportError(err)
+	}
+}
+
+func (node *Node) pull() {
+	if peers := node.peers(""); len(peers) > 0 {
+		node.send(peers[node.trader.Data.Random.IntN(len(peers))], PullRequest{Known: sortedKeys(node.seen)})
+	}
+	node.timer(time.Duration(node.gossip.Pull)*time.Millisecond, Pull{})
+}
+
+func (node *Node) answerPull(from string, request PullRequest) {
+	var response PullResponse
+	for _, coinID := range sortedKeys(node.seen) {
+		if _, ok := slices.BinarySearch(request.Known, coinID); !ok {
+			announcement := node.seen[coinID]
+			announcement.TTL = 0
+			response.Coins = append(response.Coins, announcement)
+		}
+	}
+	if len(response.Coins) > 0 {
+		node.send(from, response)
+	}
+}
+
+func (node *Node) receivePull(from string, response PullResponse) {
+	for _, announcement := range response.Coins {
+		if _, ok := node.seen[announcement.Coin.ID]; !ok {
+			node.countGossip(func(stats *GossipStats) { stats.Pulled++ })
+		}
+		node.receiveCoin(from, announcement)
+	}
+}
+
+// recordView notes how much of the coin table a trader saw when it formed a
+// cooperation ring.
+func (node *Node) recordView() {
+	coins := len(node.trader.Data.Coins)
+	node.later(func() { node.system.recordView(coins) })
+}
+
+func (system *System) recordView(coins int) {
+	stats := &system.Gossip
+	view := 1.0
+	if total := len(system.Coins); total > 0 {
+		view = min(1, float64(coins)/float64(total))
+	}
+	stats.Rings++
+	stats.Views += view
+	if view < 1 {
+		stats.Partial++
+	}
+}
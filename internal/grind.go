@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type GrindRow struct {
+	Budget int   `json:"budget"`
+	Beacon bool  `json:"beacon"`
+	Odds   Ratio `json:"odds"`
+	Seats  Ratio `json:"seats"`
+}
+
+func GrindReport(params pkg.Params, traders, members int, budgets []int, trials int, seed uint64) ([]GrindRow, error) {
+	if traders < 1 || members < 0 || members > traders {
+		return nil, fmt.Errorf("coalition of %d members does not fit in %d traders", members, traders)
+	} else if trials < 1 {
+		return nil, fmt.Errorf("number of trials must be positive")
+	} else if err := params.Validate(); err != nil {
+		return nil, err
+	} else if traders < params.VerificationMin {
+		return nil, fmt.Errorf("%d traders cannot fill a verification team of %d", traders, params.VerificationMin)
+	}
+
+	random := tools.NewRandom(seed)
+	rows := make([]GrindRow, 0, len(budgets)+1)
+	for _, budget := range budgets {
+		params.GrindBudget, params.BeaconTeams = budget, false
+		odds, seats := pkg.GrindingOdds(random, params, traders, members, trials)
+		rows = append(rows, GrindRow{Budget: budget, Odds: Ratio(odds), Seats: Ratio(seats)})
+	}
+
+	params.GrindBudget, params.BeaconTeams = 1, true
+	odds, seats := pkg.GrindingOdds(random, params, traders, members, trials)
+	rows = append(rows, GrindRow{Budget: 1, Beacon: true, Odds: Ratio(odds), Seats: Ratio(seats)})
+	return rows, nil
+}
+
+func WriteGrindReport(w io.Writer, rows []GrindRow, format string) error {
+	switch format {
+	case "text":
+		for _, row := range rows {
+			name := fmt.Sprintf("budget %d", row.Budget)
+			if row.Beacon {
+				name = "beacon"
+			}
+			if _, err := fmt.Fprintf(w, "%-12s bad-accept probability: %.4f, average coalition seats: %.2f\n", name, row.Odds, row.Seats); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(rows)
+	case "csv":
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"budget", "beacon", "odds", "seats"})
+		for _, row := range rows {
+			writer.Write([]string{strconv.Itoa(row.Budget), strconv.FormatBool(row.Beacon), row.Odds.String(), row.Seats.String()})
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unknown format %s", format)
+	}
+}
@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+func TestGrindReportRejectsParams(t *testing.T) {
+	params := pkg.DefaultParams()
+	params.VerificationMin, params.VerificationMax = 5, 3
+	if _, err := GrindReport(params, 100, 20, []int{1}, 10, 1); err == nil {
+		t.Error("an empty team size range was accepted")
+	}
+
+	params.VerificationMin, params.VerificationMax = 21, 21
+	if _, err := GrindReport(params, 5, 2, []int{1}, 10, 1); err == nil {
+		t.Error("a team larger than the traders was accepted")
+	}
+	if _, err := GrindReport(params, 100, 20, []int{1}, 10, 1); err != nil {
+		t.Error(err)
+	}
+}
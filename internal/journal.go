@@ -0,0 +1,201 @@
+package internal
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+type EventType string
+
+const (
+	EventSimulationStarted EventType = "simulation_started"
+	EventTraderCreated     EventType = "trader_created"
+	EventCoinCreated       EventType = "coin_created"
+	EventCoinSaved         EventType = "coin_saved"
+	EventCoinRejected      EventType = "coin_rejected"
+	EventCooperationFormed EventType = "cooperation_formed"
+	EventFractalSubmitted  EventType = "fractal_submitted"
+	EventVerifierVoted     EventType = "verifier_voted"
+	EventFractalAccepted   EventType = "fractal_accepted"
+	EventFractalRejected   EventType = "fractal_rejected"
+	EventTraderBanned      EventType = "trader_banned"
+	EventRoundVote         EventType = "round_vote"
+	EventRingApplied       EventType = "ring_applied"
+	EventBalanceUpdated    EventType = "balance_updated"
+)
+
+type Payload interface {
+	EventType() EventType
+}
+
+type Event struct {
+	Seq  uint64          `json:"seq"`
+	Time time.Duration   `json:"time"`
+	Type EventType       `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type SimulationStarted struct {
+	Seed     uint64     `json:"seed"`
+	Params   pkg.Params `json:"params"`
+	Topology *Topology  `json:"topology,omitempty"`
+}
+
+type TraderCreated struct {
+	Trader    pkg.Trader `json:"trader"`
+	Behavior  string     `json:"behavior,omitempty"`
+	Neighbors []string   `json:"neighbors,omitempty"`
+}
+
+type CoinCreated struct {
+	Coin pkg.CoinTable `json:"coin"`
+}
+
+type CoinSaved struct {
+	CoinID string `json:"coin_id"`
+}
+
+type CoinRejected struct {
+	CoinID   string `json:"coin_id"`
+	TraderID string `json:"trader_id"`
+	Reason   string `json:"reason"`
+}
+
+type CooperationFormed struct {
+	TraderID      string   `json:"trader_id"`
+	CooperationID string   `json:"cooperation_id"`
+	CoinIDs       []string `json:"coin_ids"`
+}
+
+type FractalSubmitted struct {
+	TraderID string          `json:"trader_id"`
+	Fractal  pkg.FractalRing `json:"fractal"`
+}
+
+type VerifierVoted struct {
+	FractalID string `json:"fractal_id"`
+	TraderID  string `json:"trader_id"`
+	Accepted  bool   `json:"accepted"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type FractalAccepted struct {
+	FractalID string `json:"fractal_id"`
+	TraderID  string `json:"trader_id"`
+}
+
+type FractalRejected struct {
+	FractalID string `json:"fractal_id"`
+	TraderID  string `json:"trader_id"`
+	Reason    string `json:"reason"`
+}
+
+type TraderBanned struct {
+	TraderID string `json:"trader_id"`
+	Until    int    `json:"until"`
+}
+
+type RoundVote struct {
+	FractalID string `json:"fractal_id"`
+	Ring      int    `json:"ring"`
+	Round     int    `json:"round"`
+	Accepted  int    `json:"accepted"`
+	Rejected  int    `json:"rejected"`
+}
+
+type RingApplied struct {
+	FractalID string  `json:"fractal_id"`
+	Ring      int     `json:"ring"`
+	Rounds    int     `json:"rounds"`
+	Money     float64 `json:"money"`
+}
+
+type BalanceUpdated struct {
+	TraderID string  `json:"trader_id"`
+	CoinID   string  `json:"coin_id"`
+	Amount   float64 `json:"amount"`
+}
+
+func (SimulationStarted) EventType() EventType { return EventSimulationStarted }
+func (TraderCreated) EventType() EventType     { return EventTraderCreated }
+func (CoinCreated) EventType() EventType       { return EventCoinCreated }
+func (CoinSaved) EventType() EventType         { return EventCoinSaved }
+func (CoinRejected) EventType() EventType      { return EventCoinRejected }
+func (CooperationFormed) EventType() EventType { return EventCooperationFormed }
+func (FractalSubmitted) EventType() EventType  { return EventFractalSubmitted }
+func (VerifierVoted) EventType() EventType     { return EventVerifierVoted }
+func (FractalAccepted) EventType() EventType   { return EventFractalAccepted }
+func (FractalRejected) EventType() EventType   { return EventFractalRejected }
+func (TraderBanned) EventType() EventType      { return EventTraderBanned }
+func (RoundVote) EventType() EventType         { return EventRoundVote }
+func (RingApplied) EventType() EventType       { return EventRingApplied }
+func (BalanceUpdated) EventType() EventType    { return EventBalanceUpdated }
+
+type Journal struct {
+	seq        uint64
+	file       *os.File
+	buffer     *bufio.Writer
+	compressor *gzip.Writer
+	encoder    *json.Encoder
+}
+
+func OpenJournal(filePath string, appendTo bool) (*Journal, error) {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendTo {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(filePath, flags, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &Journal{file: file, buffer: bufio.NewWriter(file)}
+	var w io.Writer = journal.buffer
+	if strings.HasSuffix(filePath, ".gz") {
+		journal.compressor = gzip.NewWriter(journal.buffer)
+		w = journal.compressor
+	}
+	journal.encoder = json.NewEncoder(w)
+	return journal, nil
+}
+
+func (journal *Journal) Write(now time.Duration, payload Payload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	journal.seq++
+	return journal.encoder.Encode(Event{Seq: journal.seq, Time: now, Type: payload.EventType(), Data: data})
+}
+
+func (journal *Journal) Close() error {
+	if journal.compressor != nil {
+		if err := journal.compressor.Close(); err != nil {
+			return err
+		}
+	}
+	if err := journal.buffer.Flush(); err != nil {
+		return err
+	}
+	return journal.file.Close()
+}
+
+func (system *System) SetJournal(journal *Journal) {
+	system.journal = journal
+}
+
+func (system *System) emit(payload Payload) {
+	if system.journal == nil {
+		return
+	}
+	if err := system.journal.Write(system.clock.Now(), payload); err != nil {
+		system.reportError(err)
+	}
+}
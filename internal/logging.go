@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+const (
+	ComponentSystem  = "system"
+	ComponentLedger  = "ledger"
+	ComponentTrader  = "trader"
+	ComponentNetwork = "network"
+	ComponentSweep   = "sweep"
+)
+
+func Components() []string {
+	return []string{ComponentSystem, ComponentLedger, ComponentTrader, ComponentNetwork, ComponentSweep}
+}
+
+// LogLevels holds the minimum level of every component. The empty key is the
+// level of the components not listed, which is info by default.
+type LogLevels map[string]slog.Level
+
+func (levels LogLevels) Level(component string) slog.Level {
+	if level, ok := levels[component]; ok {
+		return level
+	}
+	return levels[""]
+}
+
+func (levels LogLevels) String() string {
+	parts := make([]string, 0, len(levels))
+	for _, component := range sortedKeys(levels) {
+		if component == "" {
+			parts = append(parts, strings.ToLower(levels[component].String()))
+		} else {
+			parts = append(parts, component+"="+strings.ToLower(levels[component].String()))
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// Set parses a comma-separated list of levels, each either bare for every
+// component or given as component=level.
+func (levels *LogLevels) Set(value string) error {
+	*levels = make(LogLevels)
+	for _, part := range strings.Split(value, ",") {
+		component, name, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			component, name = "", component
+		} else if !slices.Contains(Components(), component) {
+			return errors.New("unknown log component " + component)
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return err
+		}
+		(*levels)[component] = level
+	}
+	return nil
+}
+
+type LogOptions struct {
+	Levels LogLevels
+	JSON   bool
+}
+
+// NewLogger writes text or JSON records to w. Records of a logger with a
+// component attribute are filtered by the level of that component.
+func NewLogger(w io.Writer, options LogOptions) *slog.Logger {
+	lowest := options.Levels.Level("")
+	for _, level := range options.Levels {
+		lowest = min(lowest, level)
+	}
+	handlerOptions := &slog.HandlerOptions{Level: lowest}
+	var handler slog.Handler = slog.NewTextHandler(w, handlerOptions)
+	if options.JSON {
+		handler = slog.NewJSONHandler(w, handlerOptions)
+	}
+	return slog.New(&componentHandler{Handler: handler, levels: options.Levels, level: options.Levels.Level("")})
+}
+
+type componentHandler struct {
+	slog.Handler
+	levels LogLevels
+	level  slog.Level
+}
+
+func (handler *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= handler.level
+}
+
+func (handler *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	level := handler.level
+	for _, attr := range attrs {
+		if attr.Key == "component" {
+			level = handler.levels.Level(attr.Value.String())
+		}
+	}
+	return &componentHandler{Handler: handler.Handler.WithAttrs(attrs), levels: handler.levels, level: level}
+}
+
+func (handler *componentHandler) WithGroup(name string) slog.Handler {
+	return &componentHandler{Handler: handler.Handler.WithGroup(name), levels: handler.levels, level: handler.level}
+}
@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+type MessageType string
+
+const (
+	MessageHello        MessageType = "hello"
+	MessageMint         MessageType = "mint"
+	MessageCoin         MessageType = "coin"
+	MessagePull         MessageType = "pull"
+	MessagePullRequest  MessageType = "pull_request"
+	MessagePullResponse MessageType = "pull_response"
+	MessageRetired      MessageType = "retired"
+	MessageRingCheck    MessageType = "ring_check"
+	MessageProposal     MessageType = "proposal"
+	MessageVerification MessageType = "verification"
+	MessageVerdict      MessageType = "verdict"
+	MessageAccepted     MessageType = "accepted"
+	MessageRejected     MessageType = "rejected"
+	MessageRound        MessageType = "round"
+	MessageVoteTimeout  MessageType = "vote_timeout"
+	MessageBallotCall   MessageType = "ballot_call"
+	MessageBallot       MessageType = "ballot"
+	MessageRoundResult  MessageType = "round_result"
+	MessageFinished     MessageType = "finished"
+	MessageRingClosed   MessageType = "ring_closed"
+	MessageBan          MessageType = "ban"
+)
+
+type Body interface {
+	MessageType() MessageType
+}
+
+// Hello is the first message on every connection between node daemons.
+type Hello struct {
+	Trader  pkg.Trader `json:"trader"`
+	Address string     `json:"address"`
+	Types   uint       `json:"types"`
+	Params  pkg.Params `json:"params"`
+}
+
+// Mint is a timer a trader sends itself to create its next coin.
+type Mint struct{}
+
+// CoinAnnouncement carries a coin with the time its owner minted it. TTL is
+// the number of gossip hops it may still take. In a partial-view topology,
+// Owner is the owner's public record, so that traders who are not its
+// neighbors can check the coin.
+type CoinAnnouncement struct {
+	Coin    pkg.CoinTable `json:"coin"`
+	Owner   *pkg.Trader   `json:"owner,omitempty"`
+	Created time.Duration `json:"created"`
+	TTL     int           `json:"ttl,omitempty"`
+}
+
+// Pull is a timer a trader sends itself to ask a peer for missed coins.
+type Pull struct{}
+
+type PullRequest struct {
+	Known []string `json:"known"`
+}
+
+type PullResponse struct {
+	Coins []CoinAnnouncement `json:"coins"`
+}
+
+type Retired struct{}
+
+// RingCheck is passed from trader to trader after a new coin until one of
+// them submits a fractal ring or Order runs out.
+type RingCheck struct {
+	CoinID         string   `json:"coin_id"`
+	Order          []string `json:"order"`
+	FractalCounter int      `json:"fractal_counter"`
+}
+
+type FractalProposal struct {
+	Fractal pkg.FractalRing `json:"fractal"`
+}
+
+type VerificationVote struct {
+	FractalID string `json:"fractal_id"`
+	Accepted  bool   `json:"accepted"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Verdict lists the team members whose votes did not arrive before the vote
+// timeout as Absent.
+type Verdict struct {
+	Fractal  pkg.FractalRing `json:"fractal"`
+	Proposed time.Duration   `json:"proposed"`
+	Accepted []string        `json:"accepted"`
+	Rejected []string        `json:"rejected"`
+	Absent   []string        `json:"absent"`
+}
+
+// AcceptedFractal announces a fractal ring the ledger accepted. The ledger
+// also sends its beacon after the ring and how many rings it has accepted,
+// so a trader that missed an announcement catches up with the next one.
+type AcceptedFractal struct {
+	Fractal  pkg.FractalRing `json:"fractal"`
+	Beacon   string          `json:"beacon,omitempty"`
+	Sequence int             `json:"sequence,omitempty"`
+}
+
+type RejectedFractal struct {
+	FractalID string `json:"fractal_id"`
+	Reason    string `json:"reason"`
+}
+
+// Round is a timer the submitter of a fractal ring sends itself to start a
+// voting round.
+type Round struct {
+	FractalID string `json:"fractal_id"`
+	Round     int    `json:"round"`
+}
+
+// VoteTimeout is a timer the submitter sends itself to close a vote that
+// still misses ballots. Round is -1 for the verification vote.
+type VoteTimeout struct {
+	FractalID string `json:"fractal_id"`
+	Round     int    `json:"round"`
+}
+
+type BallotCall struct {
+	Fractal pkg.FractalRing `json:"fractal"`
+	Round   int             `json:"round"`
+	Rings   []int           `json:"rings"`
+}
+
+type Ballot struct {
+	FractalID string `json:"fractal_id"`
+	Round     int    `json:"round"`
+	Votes     []bool `json:"votes"`
+}
+
+type RoundResult struct {
+	FractalID string   `json:"fractal_id"`
+	Ring      int      `json:"ring"`
+	Round     int      `json:"round"`
+	Accepted  []string `json:"accepted"`
+	Rejected  []string `json:"rejected"`
+	Absent    []string `json:"absent"`
+}
+
+type FractalFinished struct {
+	FractalID string `json:"fractal_id"`
+}
+
+type BalanceChange struct {
+	Owner  string  `json:"owner"`
+	Amount float64 `json:"amount"`
+}
+
+type RingClosed struct {
+	Ring    pkg.CooperationTable `json:"ring"`
+	Changes []BalanceChange      `json:"changes"`
+}
+
+type Ban struct {
+	Until int `json:"until"`
+}
+
+func (Hello) MessageType() MessageType            { return MessageHello }
+func (Mint) MessageType() MessageType             { return MessageMint }
+func (CoinAnnouncement) MessageType() MessageType { return MessageCoin }
+func (Pull) MessageType() MessageType             { return MessagePull }
+func (PullRequest) MessageType() MessageType      { return MessagePullRequest }
+func (PullResponse) MessageType() MessageType     { return MessagePullResponse }
+func (Retired) MessageType() MessageType          { return MessageRetired }
+func (RingCheck) MessageType() MessageType        { return MessageRingCheck }
+func (FractalProposal) MessageType() MessageType  { return MessageProposal }
+func (VerificationVote) MessageType() MessageType { return MessageVerification }
+func (Verdict) MessageType() MessageType          { return MessageVerdict }
+func (AcceptedFractal) MessageType() MessageType  { return MessageAccepted }
+func (RejectedFractal) MessageType() MessageType  { return MessageRejected }
+func (Round) MessageType() MessageType            { return MessageRound }
+func (VoteTimeout) MessageType() MessageType      { return MessageVoteTimeout }
+func (BallotCall) MessageType() MessageType       { return MessageBallotCall }
+func (Ballot) MessageType() MessageType           { return MessageBallot }
+func (RoundResult) MessageType() MessageType      { return MessageRoundResult }
+func (FractalFinished) MessageType() MessageType  { return MessageFinished }
+func (RingClosed) MessageType() MessageType       { return MessageRingClosed }
+func (Ban) MessageType() MessageType              { return MessageBan }
@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"golang.org/x/exp/maps"
+)
+
+var statusNames = [...]string{"run", "blocked", "expired", "paid"}
+
+// LiveStats are the counters of a running system.
+type LiveStats struct {
+	Time              Ratio          `json:"time"`
+	Stopped           bool           `json:"stopped"`
+	Traders           int            `json:"traders"`
+	SubmittedFractals int            `json:"submitted_fractals"`
+	Fractals          int            `json:"fractals"`
+	BadAcceptCount    int            `json:"bad_accept_count"`
+	BadRejectCount    int            `json:"bad_reject_count"`
+	Coins             map[string]int `json:"coins"`
+	BannedTraders     int            `json:"banned_traders"`
+	Messages          int            `json:"messages"`
+	Rejections        RejectionStats `json:"rejections"`
+}
+
+type TraderView struct {
+	ID           string   `json:"id"`
+	Wallet       string   `json:"wallet"`
+	Account      float64  `json:"account"`
+	Behavior     string   `json:"behavior"`
+	Submitted    int      `json:"submitted"`
+	Accepted     int      `json:"accepted"`
+	BannedUntil  int      `json:"banned_until,omitempty"`
+	Retired      bool     `json:"retired,omitempty"`
+	Neighbors    []string `json:"neighbors,omitempty"`
+	KnownCoins   int      `json:"known_coins"`
+	Cooperations int      `json:"cooperations"`
+}
+
+type CoinView struct {
+	pkg.CoinTable
+	State string `json:"state"`
+}
+
+// Inspect runs read between two clock events of a running system, or right
+// away when it is not running.
+func (system *System) Inspect(read func()) {
+	system.mutex.Lock()
+	defer system.mutex.Unlock()
+	read()
+}
+
+func (system *System) LiveStats() (stats LiveStats) {
+	system.Inspect(func() {
+		stats = LiveStats{
+			Time:              Ratio(system.clock.Now().Seconds()),
+			Stopped:           system.stopped,
+			Traders:           len(system.Traders),
+			SubmittedFractals: system.FractalCounter,
+			Fractals:          len(system.Fractals),
+			BadAcceptCount:    system.BadAcceptCount,
+			BadRejectCount:    system.BadRejectCount,
+			Coins:             make(map[string]int, len(statusNames)),
+			BannedTraders:     system.activeBans(),
+			Messages:          system.Network.Messages,
+			Rejections: RejectionStats{
+				Coins:    maps.Clone(system.Rejections.Coins),
+				Votes:    maps.Clone(system.Rejections.Votes),
+				Fractals: maps.Clone(system.Rejections.Fractals),
+			},
+		}
+		for status, count := range system.coinsByStatus() {
+			stats.Coins[statusNames[status]] = count
+		}
+	})
+	return
+}
+
+// Handler serves the live stats as JSON on /stats and in the Prometheus text
+// format on /metrics, and looks up traders, coins and fractal rings by ID.
+func (system *System) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, system.LiveStats())
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		system.LiveStats().WritePrometheus(w)
+	})
+	mux.HandleFunc("GET /traders/{id}", system.serveTrader)
+	mux.HandleFunc("GET /coins/{id}", system.serveCoin)
+	mux.HandleFunc("GET /fractals/{id}", system.serveFractal)
+	return mux
+}
+
+func (system *System) serveTrader(w http.ResponseWriter, r *http.Request) {
+	var view *TraderView
+	system.Inspect(func() {
+		trader, ok := system.Traders[r.PathValue("id")]
+		if !ok {
+			return
+		}
+		view = &TraderView{
+			ID:          trader.ID,
+			Wallet:      trader.Wallet,
+			Account:     trader.Account,
+			Behavior:    system.Behaviors[trader.ID],
+			Submitted:   system.SubmitCount[trader.ID],
+			Accepted:    system.AcceptedCount[trader.ID],
+			BannedUntil: system.bans[trader.ID],
+			Retired:     system.retired[trader.ID],
+			Neighbors:   system.Overlay[trader.ID],
+		}
+		if trader.Data != nil {
+			view.KnownCoins, view.Cooperations = len(trader.Data.Coins), len(trader.Data.Cooperations)
+		}
+	})
+	if view == nil {
+		http.Error(w, pkg.ErrTraderNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, view)
+}
+
+func (system *System) serveCoin(w http.ResponseWriter, r *http.Request) {
+	var view *CoinView
+	system.Inspect(func() {
+		if coin, ok := system.Coins[r.PathValue("id")]; ok {
+			view = &CoinView{CoinTable: coin, State: statusNames[coin.Status]}
+		}
+	})
+	if view == nil {
+		http.Error(w, pkg.ErrCoinNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, view)
+}
+
+func (system *System) serveFractal(w http.ResponseWriter, r *http.Request) {
+	var view *pkg.FractalRing
+	system.Inspect(func() {
+		if fractal, ok := system.Fractals[r.PathValue("id")]; ok {
+			copied := *fractal
+			copied.CooperationRings = slices.Clone(fractal.CooperationRings)
+			view = &copied
+		}
+	})
+	if view == nil {
+		http.Error(w, ErrFractalNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, view)
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (stats LiveStats) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP lor_%s %s\n# TYPE lor_%s %s\n", name, help, name, kind)
+	}
+	metric("virtual_time_seconds", "gauge", "Virtual time of the simulation.")
+	fmt.Fprintf(&b, "lor_virtual_time_seconds %v\n", stats.Time)
+	metric("traders", "gauge", "Number of traders.")
+	fmt.Fprintf(&b, "lor_traders %d\n", stats.Traders)
+	metric("fractals_submitted_total", "counter", "Fractal rings submitted to the ledger.")
+	fmt.Fprintf(&b, "lor_fractals_submitted_total %d\n", stats.SubmittedFractals)
+	metric("fractals_accepted_total", "counter", "Fractal rings accepted by the ledger.")
+	fmt.Fprintf(&b, "lor_fractals_accepted_total %d\n", stats.Fractals)
+	metric("bad_accepted_total", "counter", "Invalid fractal rings that were accepted.")
+	fmt.Fprintf(&b, "lor_bad_accepted_total %d\n", stats.BadAcceptCount)
+	metric("bad_rejected_total", "counter", "Valid fractal rings that were rejected.")
+	fmt.Fprintf(&b, "lor_bad_rejected_total %d\n", stats.BadRejectCount)
+	metric("coins", "gauge", "Coins in the ledger by status.")
+	for _, status := range statusNames {
+		fmt.Fprintf(&b, "lor_coins{status=\"%s\"} %d\n", status, stats.Coins[status])
+	}
+	metric("banned_traders", "gauge", "Traders the ledger currently bans.")
+	fmt.Fprintf(&b, "lor_banned_traders %d\n", stats.BannedTraders)
+	metric("messages_total", "counter", "Messages sent between traders.")
+	fmt.Fprintf(&b, "lor_messages_total %d\n", stats.Messages)
+	metric("rejections_total", "counter", "Refused coins, votes against fractal rings and rejected fractal rings by reason.")
+	for _, rejections := range []struct {
+		kind    string
+		summary ErrorSummary
+	}{{"coin", stats.Rejections.Coins}, {"vote", stats.Rejections.Votes}, {"fractal", stats.Rejections.Fractals}} {
+		for _, reason := range sortedKeys(rejections.summary) {
+			fmt.Fprintf(&b, "lor_rejections_total{kind=\"%s\",reason=\"%s\"} %d\n", rejections.kind, labelEscaper.Replace(reason), rejections.summary[reason])
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,244 @@
+package internal
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+// LedgerID is the node that keeps the global coin table, counters and bans.
+const LedgerID = "ledger"
+
+// Message is one delivery between nodes. At is the simulation time it was
+// delivered at.
+type Message struct {
+	ID   uint64
+	From string
+	To   string
+	At   time.Duration
+	Body Body
+}
+
+// Effect is work a node leaves for the coordinator, the goroutine that runs
+// the clock and owns the network queue, the ledger and the journal.
+type Effect func()
+
+// Handler handles one message. The effects it returns run on the coordinator,
+// in order, before the next message is delivered.
+type Handler func(message Message) []Effect
+
+type Network interface {
+	Subscribe(nodeID string, handler Handler)
+	Send(from, to string, body Body)
+	Broadcast(from string, body Body)
+}
+
+type inbox struct {
+	messages chan Message
+	done     chan []Effect
+}
+
+// LocalNetwork delivers messages between nodes of one process. Every
+// subscribed node owns its state and handles its inbox in its own goroutine,
+// while hosted nodes run on the coordinator itself. A delivery blocks on the
+// node's done channel until the handler returns and then applies its effects,
+// so node goroutines run strictly one at a time and the messages that arrive
+// at one instant are all handled in order before the simulation clock moves
+// on.
+// Messages between two different nodes suffer the configured faults.
+type LocalNetwork struct {
+	clock    *tools.Scheduler
+	random   *tools.Random
+	faults   Faults
+	stats    *NetworkStats
+	nodes    map[string]*inbox
+	hosted   map[string]Handler
+	ids      []string
+	groups   map[string][]int
+	queue    []Message
+	draining bool
+	sequence uint64
+	wg       sync.WaitGroup
+}
+
+func NewLocalNetwork(clock *tools.Scheduler, random *tools.Random, faults Faults, stats *NetworkStats) *LocalNetwork {
+	return &LocalNetwork{
+		clock:  clock,
+		random: random,
+		faults: faults,
+		stats:  stats,
+		nodes:  make(map[string]*inbox),
+		hosted: make(map[string]Handler),
+		groups: make(map[string][]int),
+	}
+}
+
+// Subscribe gives a node its own goroutine and inbox.
+func (network *LocalNetwork) Subscribe(nodeID string, handler Handler) {
+	node := &inbox{messages: make(chan Message), done: make(chan []Effect)}
+	network.nodes[nodeID] = node
+	network.addID(nodeID)
+
+	network.wg.Add(1)
+	go func() {
+		defer network.wg.Done()
+		for message := range node.messages {
+			node.done <- handler(message)
+		}
+	}()
+}
+
+// Host runs a node on the coordinator, for nodes that own the coordinator's
+// state.
+func (network *LocalNetwork) Host(nodeID string, handler Handler) {
+	network.hosted[nodeID] = handler
+	network.addID(nodeID)
+}
+
+func (network *LocalNetwork) addID(nodeID string) {
+	if index, found := slices.BinarySearch(network.ids, nodeID); !found {
+		network.ids = slices.Insert(network.ids, index, nodeID)
+	}
+}
+
+func (network *LocalNetwork) Send(from, to string, body Body) {
+	message := Message{From: from, To: to, Body: body}
+	if from == to {
+		network.enqueue(message)
+		return
+	}
+
+	network.stats.Messages++
+	if network.faults.Lossless() {
+		network.enqueue(message)
+		return
+	} else if network.faults.Drop > 0 && network.random.Float64() < network.faults.Drop {
+		network.stats.Dropped++
+		return
+	}
+	copies := 1
+	if network.faults.Duplicate > 0 {
+		network.sequence++
+		message.ID = network.sequence
+		if network.random.Float64() < network.faults.Duplicate {
+			network.stats.Duplicated++
+			copies++
+		}
+	}
+	for range copies {
+		if delay := network.faults.delay(network.random, from, to); delay > 0 {
+			network.clock.After(delay, func() {
+				network.enqueue(message)
+			})
+		} else {
+			network.enqueue(message)
+		}
+	}
+}
+
+func (network *LocalNetwork) enqueue(message Message) {
+	network.queue = append(network.queue, message)
+	if network.draining {
+		return
+	}
+
+	network.draining = true
+	for len(network.queue) > 0 {
+		message := network.queue[0]
+		network.queue = network.queue[1:]
+		network.deliver(message)
+	}
+	network.draining = false
+}
+
+// Broadcast sends body to every other node in a random order.
+func (network *LocalNetwork) Broadcast(from string, body Body) {
+	ids := make([]string, 0, len(network.ids))
+	for _, id := range network.ids {
+		if id != from {
+			ids = append(ids, id)
+		}
+	}
+	network.random.Shuffle(len(ids), func(i, j int) {
+		ids[i], ids[j] = ids[j], ids[i]
+	})
+	for _, id := range ids {
+		network.Send(from, id, body)
+	}
+}
+
+func (network *LocalNetwork) deliver(message Message) {
+	node, subscribed := network.nodes[message.To]
+	handler, hosted := network.hosted[message.To]
+	if !subscribed && !hosted {
+		return
+	} else if network.group(message.From) != network.group(message.To) {
+		network.stats.Dropped++
+		return
+	}
+
+	message.At = network.clock.Now()
+	var effects []Effect
+	if hosted {
+		effects = handler(message)
+	} else {
+		node.messages <- message
+		effects = <-node.done
+	}
+	for _, effect := range effects {
+		effect()
+	}
+}
+
+// Cut moves nodeIDs into partition group, away from every node outside it,
+// until the group is healed. A node cut by overlapping partitions sides with
+// the latest one that is still in force.
+func (network *LocalNetwork) Cut(group int, nodeIDs []string) {
+	for _, nodeID := range nodeIDs {
+		network.groups[nodeID] = append(network.groups[nodeID], group)
+	}
+}
+
+func (network *LocalNetwork) Heal(group int, nodeIDs []string) {
+	for _, nodeID := range nodeIDs {
+		groups := slices.DeleteFunc(network.groups[nodeID], func(g int) bool { return g == group })
+		if len(groups) == 0 {
+			delete(network.groups, nodeID)
+		} else {
+			network.groups[nodeID] = groups
+		}
+	}
+}
+
+// group is the partition a node is in, zero when it is not cut off.
+func (network *LocalNetwork) group(nodeID string) int {
+	if groups := network.groups[nodeID]; len(groups) > 0 {
+		return groups[len(groups)-1]
+	}
+	return 0
+}
+
+// deduplicate drops copies of a message the node has already handled, the
+// way a receiver would with message IDs on a real transport.
+func deduplicate(handler Handler) Handler {
+	seen := make(map[uint64]bool)
+	return func(message Message) []Effect {
+		if message.ID != 0 {
+			if seen[message.ID] {
+				return nil
+			}
+			seen[message.ID] = true
+		}
+		return handler(message)
+	}
+}
+
+// Close stops every node goroutine.
+func (network *LocalNetwork) Close() {
+	for _, node := range network.nodes {
+		close(node.messages)
+	}
+	network.wg.Wait()
+}
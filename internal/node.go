@@ -0,0 +1,368 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+// Node is the network endpoint of one trader and the only owner of its state
+// while the simulation runs. Everything else a handler does, from sending
+// messages to counting stats, is left as an effect for the coordinator, so
+// the node never touches the system itself.
+type Node struct {
+	system      *System
+	trader      *pkg.Trader
+	logger      *slog.Logger
+	gossip      Gossip
+	voteTimeout time.Duration
+	roundDelay  time.Duration
+	traders     int
+	now         time.Duration
+	effects     []Effect
+	tallies     map[string]*tally
+	seen        map[string]CoinAnnouncement
+	sequence    int
+}
+
+// tally collects the votes on a fractal ring the node submitted.
+type tally struct {
+	fractal  pkg.FractalRing
+	proposed time.Duration
+	round    int
+	open     bool
+	votes    map[string]bool
+	ballots  map[string][]bool
+	rings    []int
+}
+
+func NewNode(system *System, trader *pkg.Trader) *Node {
+	return &Node{
+		system:      system,
+		trader:      trader,
+		logger:      system.log(ComponentTrader).With("trader_id", trader.ID),
+		gossip:      system.gossip,
+		voteTimeout: system.voteTimeout(),
+		roundDelay:  system.roundDelay(),
+		traders:     len(system.Traders),
+		tallies:     make(map[string]*tally),
+		seen:        make(map[string]CoinAnnouncement),
+	}
+}
+
+func (node *Node) handle(message Message) []Effect {
+	node.now = message.At
+	switch body := message.Body.(type) {
+	case Mint:
+		node.mint()
+	case CoinAnnouncement:
+		node.receiveCoin(message.From, body)
+	case Pull:
+		node.pull()
+	case PullRequest:
+		node.answerPull(message.From, body)
+	case PullResponse:
+		node.receivePull(message.From, body)
+	case RingCheck:
+		node.checkRings(body)
+	case FractalProposal:
+		node.verify(message.From, body.Fractal)
+	case VerificationVote:
+		node.countVote(message.From, body)
+	case AcceptedFractal:
+		node.accept(body)
+	case RejectedFractal:
+		node.trader.RemoveFractalRing(body.FractalID)
+		delete(node.tallies, body.FractalID)
+	case Round:
+		node.callBallot(body)
+	case VoteTimeout:
+		node.timeout(body)
+	case BallotCall:
+		node.ballot(message.From, body)
+	case Ballot:
+		node.countBallot(message.From, body)
+	case RingClosed:
+		node.closeRing(body)
+	case Ban:
+		node.trader.Data.BanUntil = body.Until
+	}
+	effects := node.effects
+	node.effects = nil
+	return effects
+}
+
+func (node *Node) later(effect Effect) {
+	node.effects = append(node.effects, effect)
+}
+
+func (node *Node) send(to string, body Body) {
+	from := node.trader.ID
+	node.later(func() { node.system.network.Send(from, to, body) })
+}
+
+func (node *Node) broadcast(body Body) {
+	from := node.trader.ID
+	node.later(func() { node.system.network.Broadcast(from, body) })
+}
+
+func (node *Node) timer(delay time.Duration, body Body) {
+	nodeID := node.trader.ID
+	node.later(func() { node.system.timer(nodeID, delay, body) })
+}
+
+func (node *Node) emit(payload Payload) {
+	node.later(func() { node.system.emit(payload) })
+}
+
+func (node *Node) reportError(err error) {
+	node.later(func() { node.system.reportError(err) })
+}
+
+// debug logs from the coordinator, so that records keep the order of the
+// run. The arguments are only handed over when debug records are wanted.
+func (node *Node) debug(message string, args ...any) {
+	if !node.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	args = append(args, "at", node.now)
+	node.later(func() { node.logger.Debug(message, args...) })
+}
+
+// count updates the network stats of the system.
+func (node *Node) count(update func(stats *NetworkStats)) {
+	node.later(func() { update(&node.system.Network) })
+}
+
+func (node *Node) mint() {
+	trader := node.trader
+	amount := trader.Data.Random.Float64() * 10
+	if trader.Account < amount {
+		node.send(LedgerID, Retired{})
+		return
+	}
+
+	coinType := trader.Data.Random.IntN(int(trader.Data.CoinTypeCount))
+	if coin := trader.CreateCoin(amount, uint(coinType)); coin != nil {
+		announcement := CoinAnnouncement{Coin: *coin, Created: node.now}
+		if trader.Data.Neighbors != nil {
+			owner := trader.Data.Traders[trader.ID]
+			announcement.Owner = &owner
+		}
+		node.saveCoin(*coin)
+		node.announce(announcement)
+	}
+	node.timer(trader.Data.Params.RoundDuration(), Mint{})
+}
+
+func (node *Node) saveCoin(coin pkg.CoinTable) {
+	if err := node.trader.SaveCoin(coin); err != nil {
+		reason := pkg.Reason(err)
+		node.emit(CoinRejected{CoinID: coin.ID, TraderID: node.trader.ID, Reason: reason})
+		node.later(func() { node.system.Rejections.Coins[reason]++ })
+		node.debug("coin rejected", "coin_id", coin.ID, "reason", reason)
+		node.count(func(stats *NetworkStats) { stats.StaleViews++ })
+		node.reportError(err)
+	}
+}
+
+func (node *Node) checkRings(check RingCheck) {
+	cooperation, fractal := node.trader.CheckForRings(check.FractalCounter)
+	if cooperation != nil {
+		node.recordView()
+		node.emit(CooperationFormed{TraderID: node.trader.ID, CooperationID: cooperation.ID, CoinIDs: cooperation.CoinIDs})
+	}
+	if fractal != nil {
+		node.propose(*fractal)
+	} else if len(check.Order) > 0 {
+		node.send(check.Order[0], RingCheck{CoinID: check.CoinID, Order: check.Order[1:], FractalCounter: check.FractalCounter})
+	}
+}
+
+func (node *Node) propose(fractal pkg.FractalRing) {
+	node.tallies[fractal.ID] = &tally{fractal: fractal, proposed: node.now, round: -1, open: true, votes: make(map[string]bool)}
+	node.debug("fractal ring proposed", "fractal_id", fractal.ID, "rings", len(fractal.CooperationRings), "team", len(fractal.VerificationTeam))
+	node.send(LedgerID, FractalProposal{Fractal: fractal})
+	for _, traderID := range fractal.VerificationTeam {
+		node.send(traderID, FractalProposal{Fractal: fractal})
+	}
+	node.timer(node.voteTimeout, VoteTimeout{FractalID: fractal.ID, Round: -1})
+}
+
+func (node *Node) verify(submitter string, fractal pkg.FractalRing) {
+	vote := VerificationVote{FractalID: fractal.ID, Accepted: true}
+	if err := node.trader.SubmitRing(&fractal); err != nil {
+		vote.Accepted, vote.Reason = false, pkg.Reason(err)
+		node.later(func() { node.system.Rejections.Votes[vote.Reason]++ })
+		if staleView(err) {
+			node.count(func(stats *NetworkStats) { stats.StaleViews++ })
+		}
+	}
+	node.emit(VerifierVoted{FractalID: fractal.ID, TraderID: node.trader.ID, Accepted: vote.Accepted, Reason: vote.Reason})
+	node.debug("verification vote", "fractal_id", fractal.ID, "submitter", submitter, "accepted", vote.Accepted, "reason", vote.Reason)
+	node.send(submitter, vote)
+}
+
+func (node *Node) countVote(voter string, vote VerificationVote) {
+	tally, ok := node.tallies[vote.FractalID]
+	if !ok || !tally.open || tally.round != -1 {
+		return
+	}
+	tally.votes[voter] = vote.Accepted
+	if len(tally.votes) == len(tally.fractal.VerificationTeam) {
+		node.closeVerification(tally)
+	}
+}
+
+func (node *Node) closeVerification(tally *tally) {
+	tally.open = false
+	accepted, rejected, absent := split(tally.fractal.VerificationTeam, tally.votes)
+	node.send(LedgerID, Verdict{Fractal: tally.fractal, Proposed: tally.proposed, Accepted: accepted, Rejected: rejected, Absent: absent})
+}
+
+func (node *Node) timeout(timeout VoteTimeout) {
+	tally, ok := node.tallies[timeout.FractalID]
+	if !ok || !tally.open || tally.round != timeout.Round {
+		return
+	}
+
+	node.count(func(stats *NetworkStats) { stats.VoteTimeouts++ })
+	if timeout.Round == -1 {
+		node.closeVerification(tally)
+	} else {
+		node.closeRound(tally)
+	}
+}
+
+func (node *Node) accept(accepted AcceptedFractal) {
+	fractal := accepted.Fractal
+	if err := node.trader.InformFractalRing(fractal); err != nil {
+		node.count(func(stats *NetworkStats) { stats.StaleViews++ })
+		node.reportError(err)
+	}
+	// Take the ledger's beacon unless a later announcement overtook this one.
+	if accepted.Beacon != "" && accepted.Sequence > node.sequence {
+		node.trader.Data.Beacon, node.sequence = accepted.Beacon, accepted.Sequence
+	}
+
+	tally, ok := node.tallies[fractal.ID]
+	if !ok {
+		return
+	} else if !RunFractals {
+		delete(node.tallies, fractal.ID)
+		return
+	}
+	for index := range tally.fractal.CooperationRings {
+		tally.rings = append(tally.rings, index)
+	}
+	node.timer(node.roundDelay, Round{FractalID: fractal.ID, Round: 0})
+}
+
+func (node *Node) callBallot(round Round) {
+	tally, ok := node.tallies[round.FractalID]
+	if !ok {
+		return
+	} else if len(tally.rings) == 0 {
+		node.finish(tally)
+		return
+	}
+
+	tally.round, tally.open, tally.ballots = round.Round, true, make(map[string][]bool)
+	call := BallotCall{Fractal: tally.fractal, Round: round.Round, Rings: slices.Clone(tally.rings)}
+	for _, traderID := range tally.fractal.VerificationTeam {
+		node.send(traderID, call)
+	}
+	node.timer(node.voteTimeout, VoteTimeout{FractalID: round.FractalID, Round: round.Round})
+}
+
+func (node *Node) ballot(submitter string, call BallotCall) {
+	votes := make([]bool, len(call.Rings))
+	for i, ring := range call.Rings {
+		votes[i] = node.trader.Vote(&call.Fractal, ring, call.Round) == nil
+	}
+	node.debug("ballot cast", "fractal_id", call.Fractal.ID, "round", call.Round, "votes", votes)
+	node.send(submitter, Ballot{FractalID: call.Fractal.ID, Round: call.Round, Votes: votes})
+}
+
+func (node *Node) countBallot(voter string, ballot Ballot) {
+	tally, ok := node.tallies[ballot.FractalID]
+	if !ok || !tally.open || tally.round != ballot.Round || len(ballot.Votes) != len(tally.rings) {
+		return
+	}
+	tally.ballots[voter] = ballot.Votes
+	if len(tally.ballots) == len(tally.fractal.VerificationTeam) {
+		node.closeRound(tally)
+	}
+}
+
+func (node *Node) closeRound(tally *tally) {
+	tally.open = false
+	running := tally.rings[:0]
+	for i, ring := range tally.rings {
+		votes := make(map[string]bool, len(tally.ballots))
+		for traderID, ballot := range tally.ballots {
+			votes[traderID] = ballot[i]
+		}
+		accepted, rejected, absent := split(tally.fractal.VerificationTeam, votes)
+		node.send(LedgerID, RoundResult{FractalID: tally.fractal.ID, Ring: ring, Round: tally.round, Accepted: accepted, Rejected: rejected, Absent: absent})
+		if !rejectedBy(accepted, rejected, absent) {
+			running = append(running, ring)
+		}
+	}
+	tally.rings = running
+
+	if next := tally.round + 1; next < node.trader.Data.Params.RoundsCount {
+		node.timer(node.roundDelay, Round{FractalID: tally.fractal.ID, Round: next})
+	} else {
+		node.finish(tally)
+	}
+}
+
+func (node *Node) finish(tally *tally) {
+	delete(node.tallies, tally.fractal.ID)
+	node.send(LedgerID, FractalFinished{FractalID: tally.fractal.ID})
+}
+
+func (node *Node) closeRing(closed RingClosed) {
+	for _, change := range closed.Changes {
+		if _, ok := node.trader.Data.Traders[change.Owner]; !ok && node.trader.Data.Neighbors != nil {
+			continue
+		} else if err := node.trader.UpdateBalance(change.Owner, change.Amount); err != nil {
+			node.reportError(err)
+		}
+	}
+	if closed.Ring.Rounds < node.trader.Data.Params.RoundsCount {
+		node.trader.ExpireRing(closed.Ring)
+	} else {
+		node.trader.PayRing(closed.Ring)
+	}
+}
+
+// split sorts a team by its votes, keeping the team order.
+func split(team []string, votes map[string]bool) (accepted, rejected, absent []string) {
+	accepted, rejected = []string{}, []string{}
+	for _, traderID := range team {
+		if vote, ok := votes[traderID]; !ok {
+			absent = append(absent, traderID)
+		} else if vote {
+			accepted = append(accepted, traderID)
+		} else {
+			rejected = append(rejected, traderID)
+		}
+	}
+	return
+}
+
+// rejectedBy reports whether a vote fails. Absent members count against it,
+// so lost votes cannot hand the decision to a minority.
+func rejectedBy(accepted, rejected, absent []string) bool {
+	return len(rejected)+len(absent) > len(accepted)
+}
+
+func staleView(err error) bool {
+	return errors.Is(err, pkg.ErrCoinNotFound) || errors.Is(err, pkg.ErrInvalidCoinStatus)
+}
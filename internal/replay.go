@@ -0,0 +1,233 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+type replayer struct {
+	system  *System
+	pending map[string]*pkg.FractalRing
+}
+
+func ReplayFile(filePath string) (*System, int, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	return Replay(file)
+}
+
+func Replay(r io.Reader) (*System, int, error) {
+	reader := bufio.NewReader(r)
+	if prefix, _ := reader.Peek(len(gzipMagic)); bytes.Equal(prefix, gzipMagic) {
+		decompressor, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer decompressor.Close()
+		reader = bufio.NewReader(decompressor)
+	}
+
+	replayer := &replayer{
+		system:  NewSystem(0, pkg.DefaultParams()),
+		pending: make(map[string]*pkg.FractalRing),
+	}
+
+	count, decoder := 0, json.NewDecoder(reader)
+	for {
+		var event Event
+		if err := decoder.Decode(&event); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, count, err
+		}
+		if err := replayer.apply(event); err != nil {
+			return nil, count, fmt.Errorf("event %d (%s): %w", event.Seq, event.Type, err)
+		}
+		count++
+	}
+	return replayer.system, count, nil
+}
+
+func (replayer *replayer) apply(event Event) error {
+	system := replayer.system
+	switch event.Type {
+	case EventSimulationStarted:
+		var payload SimulationStarted
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return err
+		}
+		system.Seed, system.Params = payload.Seed, payload.Params
+		if payload.Topology != nil {
+			system.topology = *payload.Topology
+		}
+	case EventTraderCreated:
+		var payload TraderCreated
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return err
+		}
+		system.Traders[payload.Trader.ID] = &payload.Trader
+		if payload.Behavior != "" {
+			system.Behaviors[payload.Trader.ID] = payload.Behavior
+		}
+		if payload.Neighbors != nil {
+			if system.Overlay == nil {
+				system.Overlay = make(map[string][]string)
+			}
+			system.Overlay[payload.Trader.ID] = payload.Neighbors
+		}
+	case EventCoinCreated:
+		var payload CoinCreated
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return err
+		}
+		system.Coins[payload.Coin.ID] = payload.Coin
+	case EventFractalSubmitted:
+		var payload FractalSubmitted
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return err
+		}
+		system.FractalCounter++
+		system.SubmitCount[payload.TraderID]++
+		replayer.pending[payload.Fractal.ID] = &payload.Fractal
+	case EventFractalAccepted:
+		var payload FractalAccepted
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return err
+		}
+		fractal, ok := replayer.pending[payload.FractalID]
+		if !ok {
+			return errors.New("fractal was never submitted")
+		}
+		delete(replayer.pending, payload.FractalID)
+
+		replayer.setStatus(fractal, pkg.Blocked)
+		system.Fractals[fractal.ID] = fractal
+		system.Beacon = pkg.NextBeacon(system.Beacon, fractal.ID)
+		system.AcceptedCount[payload.TraderID]++
+		if !fractal.IsValid {
+			system.BadAcceptCount++
+		}
+	case EventFractalRejected:
+		var payload FractalRejected
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return err
+		}
+		fractal, ok := replayer.pending[payload.FractalID]
+		if !ok {
+			return errors.New("fractal was never submitted")
+		}
+		delete(replayer.pending, payload.FractalID)
+
+		system.Rejections.Fractals[payload.Reason]++
+		if fractal.IsValid {
+			system.BadRejectCount++
+		}
+	case EventRingApplied:
+		var payload RingApplied
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return err
+		}
+		fractal, ok := system.Fractals[payload.FractalID]
+		if !ok {
+			return errors.New("fractal not found")
+		} else if payload.Ring < 0 || payload.Ring >= len(fractal.CooperationRings) {
+			return errors.New("cooperation ring not found")
+		}
+
+		ring := fractal.CooperationRings[payload.Ring]
+		ring.Rounds = payload.Rounds
+		fractal.CooperationRings[payload.Ring] = ring
+
+		status := pkg.Paid
+		if ring.Rounds < system.Params.RoundsCount {
+			status = pkg.Expired
+		}
+		for _, coinID := range ring.CoinIDs {
+			coin := system.Coins[coinID]
+			coin.Status = status
+			system.Coins[coinID] = coin
+		}
+	case EventCoinRejected:
+		var payload CoinRejected
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return err
+		}
+		system.Rejections.Coins[payload.Reason]++
+	case EventVerifierVoted:
+		var payload VerifierVoted
+		if err := json.Unmarshal(event.Data, &payload); err != nil {
+			return err
+		}
+		if !payload.Accepted {
+			system.Rejections.Votes[payload.Reason]++
+		}
+	case EventCoinSaved, EventCooperationFormed, EventTraderBanned, EventRoundVote, EventBalanceUpdated:
+	default:
+		return errors.New("unknown event type")
+	}
+	return nil
+}
+
+func (replayer *replayer) setStatus(fractal *pkg.FractalRing, status pkg.Status) {
+	for _, ring := range fractal.CooperationRings {
+		for _, coinID := range ring.CoinIDs {
+			coin := replayer.system.Coins[coinID]
+			coin.Status = status
+			replayer.system.Coins[coinID] = coin
+		}
+	}
+}
+
+func CompareSystems(expected, actual *System) []string {
+	var differences []string
+	compare := func(name string, a, b any) {
+		dataA, errA := json.Marshal(a)
+		dataB, errB := json.Marshal(b)
+		if errA != nil || errB != nil || !bytes.Equal(dataA, dataB) {
+			differences = append(differences, name)
+		}
+	}
+
+	compare("seed", expected.Seed, actual.Seed)
+	compare("params", expected.Params, actual.Params)
+	compare("bad accept count", expected.BadAcceptCount, actual.BadAcceptCount)
+	compare("bad reject count", expected.BadRejectCount, actual.BadRejectCount)
+	compare("fractal counter", expected.FractalCounter, actual.FractalCounter)
+	compare("submit count", expected.SubmitCount, actual.SubmitCount)
+	compare("accepted count", expected.AcceptedCount, actual.AcceptedCount)
+	compare("behaviors", expected.Behaviors, actual.Behaviors)
+	compare("rejections", expected.Rejections, actual.Rejections)
+	compare("beacon", expected.Beacon, actual.Beacon)
+
+	compareMaps(&differences, "trader", expected.Traders, actual.Traders, compare)
+	compareMaps(&differences, "coin", expected.Coins, actual.Coins, compare)
+	compareMaps(&differences, "fractal", expected.Fractals, actual.Fractals, compare)
+	return differences
+}
+
+func compareMaps[V any](differences *[]string, name string, expected, actual map[string]V, compare func(string, any, any)) {
+	for _, key := range sortedKeys(expected) {
+		if value, ok := actual[key]; !ok {
+			*differences = append(*differences, fmt.Sprintf("%s %s is missing", name, key))
+		} else {
+			compare(fmt.Sprintf("%s %s", name, key), expected[key], value)
+		}
+	}
+	for _, key := range sortedKeys(actual) {
+		if _, ok := expected[key]; !ok {
+			*differences = append(*differences, fmt.Sprintf("%s %s is unexpected", name, key))
+		}
+	}
+}
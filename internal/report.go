@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+type Ratio float64
+
+func (r Ratio) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(r)) || math.IsInf(float64(r), 0) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(float64(r))
+}
+
+func (r Ratio) String() string {
+	return strconv.FormatFloat(float64(r), 'f', -1, 64)
+}
+
+func WriteMetrics(w io.Writer, metrics Metrics, format string) error {
+	switch format {
+	case "text":
+		return metrics.WriteText(w)
+	case "json":
+		return metrics.WriteJSON(w)
+	case "csv":
+		return metrics.WriteCSV(w)
+	default:
+		return fmt.Errorf("unknown format %s", format)
+	}
+}
+
+func (metrics Metrics) WriteText(w io.Writer) error {
+	lines := []string{
+		fmt.Sprintln("Number of coins:", metrics.Coins),
+		fmt.Sprintln("Number of fractal rings:", metrics.Fractals),
+		fmt.Sprintln("Number of run coins:", metrics.RunCoins),
+		fmt.Sprintf("Average number of submitted fractal rings per trader: %.2f\n", metrics.AverageSubmitted),
+		fmt.Sprintf("Average fractal ring acceptance rate per trader: %.2f%%\n", metrics.AcceptRate*100),
+		fmt.Sprintln("Number of invalid accepted fractal rings:", metrics.BadAcceptCount),
+		fmt.Sprintln("Number of valid rejected fractal rings:", metrics.BadRejectCount),
+	}
+	if RunFractals {
+		lines = append(lines,
+			fmt.Sprintf("Average satisfaction per coin: %.2f%%\n", metrics.CoinSatisfaction*100),
+			fmt.Sprintf("Average satisfaction per trader: %.2f%%\n", metrics.TraderSatisfaction*100),
+			fmt.Sprintf("Average adjacency per trader: %.2f\n", metrics.AverageAdjacency),
+			fmt.Sprintln("Maximum adjacency per trader:", metrics.MaximumAdjacency),
+			fmt.Sprintln("Maximum cooperation ring count:", metrics.MaximumRingCount),
+		)
+	}
+
+	if metrics.CoalitionSize > 0 {
+		lines = append(lines,
+			fmt.Sprintln("Coalition size:", metrics.CoalitionSize),
+			fmt.Sprintln("Number of fractal rings submitted by the coalition:", metrics.CoalitionSubmitted),
+			fmt.Sprintf("Coalition fractal ring acceptance rate: %.2f%%\n", metrics.CoalitionAcceptRate*100),
+			fmt.Sprintln("Number of invalid accepted coalition fractal rings:", metrics.CoalitionBadAccepted),
+			fmt.Sprintln("Number of accepted fractal rings with a coalition majority team:", metrics.CapturedTeams),
+		)
+	}
+
+	for _, principal := range metrics.Principals {
+		lines = append(lines, fmt.Sprintln(principal))
+	}
+	if metrics.MajorityWallets > 0 {
+		lines = append(lines, fmt.Sprintln("Wallets needed for an even chance of a team majority:", metrics.MajorityWallets))
+	}
+
+	if metrics.Dropped+metrics.Duplicated+metrics.StaleViews+metrics.CheckCoinsFailures+metrics.VoteTimeouts > 0 || metrics.MaxVerdictTime > 0 {
+		lines = append(lines,
+			fmt.Sprintln("Number of messages between traders:", metrics.Messages),
+			fmt.Sprintln("Number of dropped messages:", metrics.Dropped),
+			fmt.Sprintln("Number of duplicated messages:", metrics.Duplicated),
+			fmt.Sprintln("Number of stale coin views:", metrics.StaleViews),
+			fmt.Sprintln("Number of failed coin checks:", metrics.CheckCoinsFailures),
+			fmt.Sprintln("Number of timed out votes:", metrics.VoteTimeouts),
+			fmt.Sprintf("Average time to verdict: %.3fs\n", metrics.MeanVerdictTime),
+			fmt.Sprintf("Maximum time to verdict: %.3fs\n", metrics.MaxVerdictTime),
+		)
+	}
+
+	if metrics.GossipFanout > 0 {
+		lines = append(lines,
+			fmt.Sprintln("Gossip fanout:", metrics.GossipFanout),
+			fmt.Sprintf("Coin coverage: %.2f%%\n", metrics.Coverage*100),
+			fmt.Sprintf("Redundant coin messages per coin: %.2f\n", metrics.RedundantPerCoin),
+			fmt.Sprintln("Number of coins learned by pulling:", metrics.PulledCoins),
+			fmt.Sprintf("Average coin propagation delay: %.3fs\n", metrics.MeanPropagation),
+			fmt.Sprintf("Maximum coin propagation delay: %.3fs\n", metrics.MaxPropagation),
+			fmt.Sprintln("Number of cooperation rings formed on a partial coin view:", metrics.PartialViewRings),
+			fmt.Sprintf("Average coin view when forming a cooperation ring: %.2f%%\n", metrics.MeanViewAtRing*100),
+		)
+	}
+
+	if metrics.Topology != TopologyComplete {
+		lines = append(lines,
+			fmt.Sprintln("Overlay topology:", metrics.Topology),
+			fmt.Sprintf("Average trader degree: %.2f\n", metrics.MeanDegree),
+			fmt.Sprintln("Maximum trader degree:", metrics.MaxDegree),
+			fmt.Sprintf("Average clustering coefficient: %.3f\n", metrics.Clustering),
+			fmt.Sprintf("Average overlay path length: %.2f\n", metrics.MeanPathLength),
+			fmt.Sprintf("Average overlay distance from a submitter to its coin owners: %.2f\n", metrics.OwnerDistance),
+		)
+	}
+
+	for _, rejections := range []struct {
+		name    string
+		summary ErrorSummary
+	}{
+		{"coins refused by traders", metrics.CoinRejections},
+		{"votes against fractal rings", metrics.VoteRejections},
+		{"fractal rings rejected by the ledger", metrics.FractalRejections},
+	} {
+		if rejections.summary.Total() > 0 {
+			lines = append(lines, fmt.Sprintf("Number of %s: %d (%s)\n", rejections.name, rejections.summary.Total(), rejections.summary))
+		}
+	}
+
+	for _, line := range lines {
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (metrics Metrics) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(metrics)
+}
+
+func (metrics Metrics) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{
+		"coins", "fractals", "run_coins", "submitted_fractals", "average_submitted", "accept_rate",
+		"bad_accept_count", "bad_reject_count", "coin_satisfaction", "trader_satisfaction",
+		"average_adjacency", "maximum_adjacency", "maximum_ring_count",
+		"coalition_size", "coalition_submitted", "coalition_accepted", "coalition_bad_accept_count",
+		"captured_teams", "coalition_accept_rate",
+		"messages", "dropped", "duplicated", "stale_views", "check_coins_failures", "vote_timeouts",
+		"mean_verdict_time", "max_verdict_time",
+		"gossip_fanout", "coverage", "redundant_per_coin", "pulled_coins", "mean_propagation", "max_propagation",
+		"partial_view_rings", "mean_view_at_ring",
+		"topology", "mean_degree", "max_degree", "clustering", "mean_path_length", "owner_distance",
+		"coin_rejections", "vote_rejections", "fractal_rejections",
+	})
+	writer.Write([]string{
+		strconv.Itoa(metrics.Coins), strconv.Itoa(metrics.Fractals), strconv.Itoa(metrics.RunCoins),
+		strconv.Itoa(metrics.SubmittedFractals), metrics.AverageSubmitted.String(), metrics.AcceptRate.String(),
+		strconv.Itoa(metrics.BadAcceptCount), strconv.Itoa(metrics.BadRejectCount),
+		metrics.CoinSatisfaction.String(), metrics.TraderSatisfaction.String(),
+		metrics.AverageAdjacency.String(), strconv.Itoa(metrics.MaximumAdjacency), strconv.Itoa(metrics.MaximumRingCount),
+		strconv.Itoa(metrics.CoalitionSize), strconv.Itoa(metrics.CoalitionSubmitted), strconv.Itoa(metrics.CoalitionAccepted),
+		strconv.Itoa(metrics.CoalitionBadAccepted), strconv.Itoa(metrics.CapturedTeams), metrics.CoalitionAcceptRate.String(),
+		strconv.Itoa(metrics.Messages), strconv.Itoa(metrics.Dropped), strconv.Itoa(metrics.Duplicated),
+		strconv.Itoa(metrics.StaleViews), strconv.Itoa(metrics.CheckCoinsFailures), strconv.Itoa(metrics.VoteTimeouts),
+		metrics.MeanVerdictTime.String(), metrics.MaxVerdictTime.String(),
+		strconv.Itoa(metrics.GossipFanout), metrics.Coverage.String(), metrics.RedundantPerCoin.String(),
+		strconv.Itoa(metrics.PulledCoins), metrics.MeanPropagation.String(), metrics.MaxPropagation.String(),
+		strconv.Itoa(metrics.PartialViewRings), metrics.MeanViewAtRing.String(),
+		metrics.Topology, metrics.MeanDegree.String(), strconv.Itoa(metrics.MaxDegree),
+		metrics.Clustering.String(), metrics.MeanPathLength.String(), metrics.OwnerDistance.String(),
+		strconv.Itoa(metrics.CoinRejections.Total()), strconv.Itoa(metrics.VoteRejections.Total()), strconv.Itoa(metrics.FractalRejections.Total()),
+	})
+	writer.Flush()
+	return writer.Error()
+}
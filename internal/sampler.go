@@ -0,0 +1,109 @@
+package internal
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+type Sample struct {
+	Time           time.Duration
+	FractalCounter int
+	Fractals       int
+	BadAcceptCount int
+	BadRejectCount int
+	CoinsByStatus  [4]int
+	ActiveBans     int
+}
+
+func (system *System) StartSampling(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	system.clock.After(interval, func() {
+		system.TakeSample()
+		if !system.stopped || system.clock.Pending() > 0 {
+			system.StartSampling(interval)
+		}
+	})
+}
+
+func (system *System) TakeSample() {
+	if n := len(system.samples); n > 0 && system.samples[n-1].Time == system.clock.Now() {
+		return
+	}
+
+	sample := Sample{
+		Time:           system.clock.Now(),
+		FractalCounter: system.FractalCounter,
+		Fractals:       len(system.Fractals),
+		BadAcceptCount: system.BadAcceptCount,
+		BadRejectCount: system.BadRejectCount,
+		CoinsByStatus:  system.coinsByStatus(),
+		ActiveBans:     system.activeBans(),
+	}
+	system.samples = append(system.samples, sample)
+}
+
+func (system *System) coinsByStatus() (counts [4]int) {
+	for _, coin := range system.Coins {
+		if int(coin.Status) < len(counts) {
+			counts[coin.Status]++
+		}
+	}
+	return
+}
+
+// activeBans counts the traders the ledger still bans.
+func (system *System) activeBans() (count int) {
+	for _, until := range system.bans {
+		if until > system.FractalCounter {
+			count++
+		}
+	}
+	return
+}
+
+func (system *System) Samples() []Sample {
+	return system.samples
+}
+
+func SamplesPath(snapshotPath string) string {
+	snapshotPath = strings.TrimSuffix(snapshotPath, ".gz")
+	return strings.TrimSuffix(snapshotPath, filepath.Ext(snapshotPath)) + ".samples.csv"
+}
+
+func (system *System) SaveSamples(filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{
+		"time", "fractal_counter", "fractals", "bad_accept_count", "bad_reject_count",
+		"run_coins", "blocked_coins", "expired_coins", "paid_coins", "active_bans",
+	})
+	for _, sample := range system.samples {
+		writer.Write([]string{
+			strconv.FormatFloat(sample.Time.Seconds(), 'f', -1, 64),
+			strconv.Itoa(sample.FractalCounter),
+			strconv.Itoa(sample.Fractals),
+			strconv.Itoa(sample.BadAcceptCount),
+			strconv.Itoa(sample.BadRejectCount),
+			strconv.Itoa(sample.CoinsByStatus[pkg.Run]),
+			strconv.Itoa(sample.CoinsByStatus[pkg.Blocked]),
+			strconv.Itoa(sample.CoinsByStatus[pkg.Expired]),
+			strconv.Itoa(sample.CoinsByStatus[pkg.Paid]),
+			strconv.Itoa(sample.ActiveBans),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
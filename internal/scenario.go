@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+type BehaviorGroup struct {
+	Behavior string `json:"behavior"`
+	Count    int    `json:"count"`
+}
+
+type BehaviorGroups []BehaviorGroup
+
+type Scenario struct {
+	Types     uint           `json:"types"`
+	Time      int            `json:"time"`
+	Traders   int            `json:"traders"`
+	Randoms   int            `json:"randoms"`
+	Bads      int            `json:"bads"`
+	Behaviors BehaviorGroups `json:"behaviors,omitempty"`
+	Sybils    int            `json:"sybils,omitempty"`
+	Wallets   int            `json:"wallets,omitempty"`
+	Seed      uint64         `json:"seed"`
+	Sample    int            `json:"sample_interval,omitempty"`
+	Faults    Faults         `json:"faults"`
+	Gossip    Gossip         `json:"gossip"`
+	Topology  Topology       `json:"topology"`
+	Params    pkg.Params     `json:"params"`
+}
+
+func (groups *BehaviorGroups) String() string {
+	if groups == nil {
+		return ""
+	}
+	parts := make([]string, len(*groups))
+	for i, group := range *groups {
+		parts[i] = fmt.Sprintf("%s=%d", group.Behavior, group.Count)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (groups *BehaviorGroups) Set(value string) error {
+	*groups = nil
+	for _, part := range strings.Split(value, ",") {
+		if part == "" {
+			continue
+		}
+		name, count, ok := strings.Cut(part, "=")
+		if !ok {
+			return fmt.Errorf("behavior group %s must be name=count", part)
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			return err
+		}
+		*groups = append(*groups, BehaviorGroup{Behavior: name, Count: n})
+	}
+	return nil
+}
+
+func (groups *BehaviorGroups) SetCount(name string, count int) {
+	for i, group := range *groups {
+		if group.Behavior == name {
+			clone := append(BehaviorGroups(nil), *groups...)
+			clone[i].Count = count
+			*groups = clone
+			return
+		}
+	}
+	*groups = append(append(BehaviorGroups(nil), *groups...), BehaviorGroup{Behavior: name, Count: count})
+}
+
+func DefaultScenario() Scenario {
+	return Scenario{
+		Types:   3,
+		Time:    60,
+		Traders: 100,
+		Randoms: 0,
+		Bads:    0,
+		Seed:    0,
+		Params:  pkg.DefaultParams(),
+	}
+}
+
+// LoadScenario reads a JSON scenario file, or a YAML one if its extension is
+// .yaml or .yml.
+func LoadScenario(filePath string) (Scenario, error) {
+	scenario := DefaultScenario()
+	if err := readConfig(filePath, &scenario); err != nil {
+		return scenario, err
+	}
+	return scenario, nil
+}
+
+// readConfig decodes a JSON or YAML file into v. YAML is converted to JSON
+// first, so both formats use the same field names.
+func readConfig(filePath string, v any) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		var document any
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return err
+		} else if data, err = json.Marshal(document); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (scenario Scenario) Validate() error {
+	if scenario.Types < 1 {
+		return errors.New("number of types must be positive")
+	} else if scenario.Traders < 1 {
+		return errors.New("number of traders must be positive")
+	} else if scenario.Time < 0 {
+		return errors.New("run time must be non-negative")
+	} else if scenario.Sample < 0 {
+		return errors.New("sample interval must be non-negative")
+	} else if scenario.Randoms < 0 || scenario.Bads < 0 {
+		return errors.New("number of random and bad traders must be non-negative")
+	} else if scenario.Randoms+scenario.Bads > scenario.Traders {
+		return errors.New("number of random and bad traders must be less than the total number of traders")
+	}
+
+	if scenario.Sybils < 0 || scenario.Wallets < 0 {
+		return errors.New("number of sybil principals and wallets must be non-negative")
+	}
+
+	total := scenario.Randoms + scenario.Bads + scenario.Sybils*scenario.Wallets
+	for _, group := range scenario.Behaviors {
+		if _, err := pkg.NewBehavior(group.Behavior, pkg.NewSociety()); err != nil {
+			return err
+		} else if group.Count < 0 {
+			return fmt.Errorf("number of %s traders must be non-negative", group.Behavior)
+		}
+		total += group.Count
+	}
+	if total > scenario.Traders {
+		return errors.New("number of traders with a behavior must be less than the total number of traders")
+	} else if err := scenario.Faults.Validate(); err != nil {
+		return err
+	} else if err := scenario.Gossip.Validate(); err != nil {
+		return err
+	} else if err := scenario.Topology.Validate(scenario.Traders); err != nil {
+		return err
+	} else if !scenario.Topology.Complete() && scenario.Topology.MinDegree()+1 < scenario.Params.VerificationMin {
+		return fmt.Errorf("%s topology leaves some traders only %d neighbors, too few for a verification team", scenario.Topology.Kind, scenario.Topology.MinDegree())
+	}
+	return scenario.Params.Validate()
+}
+
+func (scenario Scenario) TraderBehaviors() []string {
+	groups := append(BehaviorGroups{{pkg.BehaviorRandom, scenario.Randoms}, {pkg.BehaviorBad, scenario.Bads}}, scenario.Behaviors...)
+	for i := 1; i <= scenario.Sybils; i++ {
+		groups = append(groups, BehaviorGroup{pkg.GroupName(pkg.BehaviorSybil, strconv.Itoa(i)), scenario.Wallets})
+	}
+	behaviors := make([]string, 0, scenario.Traders)
+	for _, group := range groups {
+		for i := 0; i < group.Count; i++ {
+			behaviors = append(behaviors, group.Behavior)
+		}
+	}
+	for len(behaviors) < scenario.Traders {
+		behaviors = append(behaviors, pkg.BehaviorNormal)
+	}
+	return behaviors
+}
+
+func (scenario Scenario) RunTime() time.Duration {
+	return time.Duration(scenario.Time) * time.Second
+}
+
+func (scenario Scenario) SampleInterval() time.Duration {
+	return time.Duration(scenario.Sample) * time.Second
+}
+
+// Run creates and runs the scenario's system. If ctx is cancelled, it still
+// returns the drained system along with the error.
+func (scenario Scenario) Run(ctx context.Context, logger *slog.Logger, journal *Journal) (*System, error) {
+	system := scenario.System(logger, journal)
+	if err := scenario.Init(system); err != nil {
+		return nil, err
+	}
+	return system, system.Run(ctx, scenario.RunTime(), scenario.SampleInterval())
+}
+
+// System creates the scenario's system without any traders yet.
+func (scenario Scenario) System(logger *slog.Logger, journal *Journal) *System {
+	system := NewSystem(scenario.Seed, scenario.Params)
+	system.SetLogger(logger)
+	system.SetJournal(journal)
+	system.SetFaults(scenario.Faults)
+	system.SetGossip(scenario.Gossip)
+	system.SetTopology(scenario.Topology)
+	return system
+}
+
+// Init creates the scenario's traders in system.
+func (scenario Scenario) Init(system *System) error {
+	system.log(ComponentSystem).Info("starting simulation", "types", scenario.Types, "alpha", scenario.Params.BadBehavior, "seed", scenario.Seed)
+	if err := system.Init(scenario.TraderBehaviors(), scenario.Types); err != nil {
+		return err
+	}
+	system.log(ComponentSystem).Info("simulation initialized")
+	return nil
+}
+
+func (system *System) Run(ctx context.Context, runTime, sampleInterval time.Duration) error {
+	system.log(ComponentSystem).Info("running simulation", "run_time", runTime, "at", system.Now())
+	start := time.Now()
+	system.StartSampling(sampleInterval)
+	summary, err := system.Start(ctx, runTime)
+	if sampleInterval > 0 {
+		system.TakeSample()
+	}
+	system.log(ComponentSystem).Info("simulation stopped", "at", system.Now(), "elapsed", time.Since(start).Round(time.Millisecond))
+	if total := summary.Total(); total > 0 {
+		system.log(ComponentSystem).Warn("errors during the run", "errors", summary.String(), "total", total)
+	}
+	return err
+}
@@ -0,0 +1,34 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadScenarioYAML(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"scenario.json": `{"traders": 30, "seed": 7, "faults": {"latency": 20, "partitions": [{"start": 100, "duration": 200, "fraction": 0.5}]}, "params": {"verification_min": 5, "scheme": "ed25519"}}`,
+		"scenario.yaml": "traders: 30\nseed: 7\nfaults:\n  latency: 20\n  partitions:\n    - {start: 100, duration: 200, fraction: 0.5}\nparams:\n  verification_min: 5\n  scheme: ed25519\n",
+	}
+	scenarios := make(map[string]Scenario)
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		scenario, err := LoadScenario(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		scenarios[name] = scenario
+	}
+
+	if json, yaml := scenarios["scenario.json"], scenarios["scenario.yaml"]; !reflect.DeepEqual(json, yaml) {
+		t.Errorf("YAML scenario %+v differs from JSON scenario %+v", yaml, json)
+	} else if yaml.Traders != 30 || yaml.Params.VerificationMin != 5 || yaml.Params.FractalMax != DefaultScenario().Params.FractalMax {
+		t.Errorf("YAML scenario %+v was not loaded over the defaults", yaml)
+	}
+}
@@ -0,0 +1,354 @@
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+	"golang.org/x/exp/maps"
+)
+
+const (
+	SnapshotMagic   = "lor-snapshot"
+	SnapshotVersion = 3
+
+	// samplerVersion is the first version whose cooperation rings were drawn
+	// by the DRBG sampler. Older rings no longer validate.
+	samplerVersion = 3
+
+	KindSnapshot   = "snapshot"
+	KindCheckpoint = "checkpoint"
+)
+
+var (
+	gzipMagic     = []byte{0x1f, 0x8b}
+	snapshotMagic = []byte(`{"magic":"` + SnapshotMagic + `"`)
+)
+
+type snapshotHeader struct {
+	Magic   string `json:"magic"`
+	Version int    `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+type snapshotRecord struct {
+	Type  string          `json:"type"`
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+type systemRecord struct {
+	Seed           uint64              `json:"seed"`
+	Params         pkg.Params          `json:"params"`
+	BadAcceptCount int                 `json:"bad_accept_count"`
+	BadRejectCount int                 `json:"bad_reject_count"`
+	FractalCounter int                 `json:"fractal_counter"`
+	SubmitCount    map[string]int      `json:"submit_count"`
+	AcceptedCount  map[string]int      `json:"accepted_count"`
+	Behaviors      map[string]string   `json:"behaviors,omitempty"`
+	Network        NetworkStats        `json:"network"`
+	Gossip         GossipStats         `json:"gossip"`
+	Rejections     RejectionStats      `json:"rejections"`
+	Topology       *Topology           `json:"topology,omitempty"`
+	Overlay        map[string][]string `json:"overlay,omitempty"`
+	Beacon         string              `json:"beacon,omitempty"`
+
+	Clock   time.Duration `json:"clock,omitempty"`
+	Random  []byte        `json:"random,omitempty"`
+	Retired []string      `json:"retired,omitempty"`
+}
+
+func (system *System) Save(filePath string) error {
+	return system.saveAs(filePath, KindSnapshot)
+}
+
+func (system *System) SaveCheckpoint(filePath string) error {
+	return system.saveAs(filePath, KindCheckpoint)
+}
+
+func (system *System) saveAs(filePath, kind string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	buffer := bufio.NewWriter(file)
+	var w io.Writer = buffer
+	var compressor *gzip.Writer
+	if strings.HasSuffix(filePath, ".gz") {
+		compressor = gzip.NewWriter(buffer)
+		w = compressor
+	}
+
+	if err := system.WriteSnapshot(w, kind); err != nil {
+		return err
+	}
+	if compressor != nil {
+		if err := compressor.Close(); err != nil {
+			return err
+		}
+	}
+	if err := buffer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func (system *System) WriteSnapshot(w io.Writer, kind string) error {
+	encoder := json.NewEncoder(w)
+	write := func(recordType, key string, value any) error {
+		return encoder.Encode(struct {
+			Type  string `json:"type"`
+			Key   string `json:"key,omitempty"`
+			Value any    `json:"value"`
+		}{recordType, key, value})
+	}
+
+	if err := encoder.Encode(snapshotHeader{Magic: SnapshotMagic, Version: SnapshotVersion, Kind: kind}); err != nil {
+		return err
+	}
+
+	record := systemRecord{
+		Seed:           system.Seed,
+		Params:         system.Params,
+		BadAcceptCount: system.BadAcceptCount,
+		BadRejectCount: system.BadRejectCount,
+		FractalCounter: system.FractalCounter,
+		SubmitCount:    system.SubmitCount,
+		AcceptedCount:  system.AcceptedCount,
+		Behaviors:      system.Behaviors,
+		Network:        system.Network,
+		Gossip:         system.Gossip,
+		Rejections:     system.Rejections,
+		Overlay:        system.Overlay,
+		Beacon:         system.Beacon,
+	}
+	if !system.topology.Complete() {
+		record.Topology = &system.topology
+	}
+	var checkpoint *Checkpoint
+	if kind == KindCheckpoint {
+		c, err := system.Checkpoint()
+		if err != nil {
+			return err
+		}
+		checkpoint = c
+		record.Clock, record.Random, record.Retired = c.Clock, c.Random, c.Retired
+	}
+	if err := write("system", "", record); err != nil {
+		return err
+	}
+
+	for _, traderID := range system.traderIDs() {
+		if err := write("trader", traderID, system.Traders[traderID]); err != nil {
+			return err
+		}
+	}
+	for _, coinID := range sortedKeys(system.Coins) {
+		if err := write("coin", coinID, system.Coins[coinID]); err != nil {
+			return err
+		}
+	}
+	for _, fractalID := range sortedKeys(system.Fractals) {
+		if err := write("fractal", fractalID, system.Fractals[fractalID]); err != nil {
+			return err
+		}
+	}
+
+	if checkpoint != nil {
+		for _, traderID := range system.traderIDs() {
+			if err := write("trader_state", traderID, checkpoint.Traders[traderID]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := maps.Keys(m)
+	slices.Sort(keys)
+	return keys
+}
+
+func Load(filePath string) (*System, error) {
+	system, _, err := loadFrom(filePath)
+	return system, err
+}
+
+func Resume(filePath string) (*System, error) {
+	system, kind, err := loadFrom(filePath)
+	if err != nil {
+		return nil, err
+	} else if kind != KindCheckpoint {
+		return nil, fmt.Errorf("%s is a %s, not a checkpoint", filePath, kind)
+	}
+	return system, nil
+}
+
+func loadFrom(filePath string) (*System, string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer file.Close()
+
+	return ReadSnapshot(file)
+}
+
+func ReadSnapshot(r io.Reader) (*System, string, error) {
+	reader := bufio.NewReader(r)
+	if prefix, _ := reader.Peek(len(gzipMagic)); bytes.Equal(prefix, gzipMagic) {
+		decompressor, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, "", err
+		}
+		defer decompressor.Close()
+		reader = bufio.NewReader(decompressor)
+	}
+
+	if prefix, _ := reader.Peek(len(snapshotMagic)); !bytes.Equal(prefix, snapshotMagic) {
+		return readLegacySnapshot(reader)
+	}
+
+	decoder := json.NewDecoder(reader)
+	var header snapshotHeader
+	if err := decoder.Decode(&header); err != nil {
+		return nil, "", err
+	} else if header.Version > SnapshotVersion {
+		return nil, "", fmt.Errorf("snapshot version %d is newer than supported version %d", header.Version, SnapshotVersion)
+	}
+
+	system := NewSystem(0, pkg.DefaultParams())
+	checkpoint := &Checkpoint{System: system, Traders: make(map[string]pkg.TraderState)}
+	for {
+		var record snapshotRecord
+		if err := decoder.Decode(&record); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, "", err
+		}
+		if err := checkpoint.apply(record); err != nil {
+			return nil, "", fmt.Errorf("%s record %s: %w", record.Type, record.Key, err)
+		}
+	}
+
+	if header.Kind == KindCheckpoint {
+		if err := checkpoint.checkSampler(header.Version); err != nil {
+			return nil, "", err
+		}
+		s, err := checkpoint.Restore()
+		return s, header.Kind, err
+	}
+	return system, header.Kind, nil
+}
+
+// checkSampler refuses a checkpoint written before samplerVersion that still
+// has cooperation rings waiting for a fractal ring, since their selection
+// would fail verification after resuming.
+func (checkpoint *Checkpoint) checkSampler(version int) error {
+	if version >= samplerVersion {
+		return nil
+	}
+	for _, traderID := range sortedKeys(checkpoint.Traders) {
+		if len(checkpoint.Traders[traderID].Cooperations) > 0 {
+			return fmt.Errorf("checkpoint version %d has cooperation rings drawn by the old sampler, so it cannot be resumed", version)
+		}
+	}
+	return nil
+}
+
+func (checkpoint *Checkpoint) apply(record snapshotRecord) error {
+	system := checkpoint.System
+	switch record.Type {
+	case "system":
+		systemRecord := systemRecord{Params: system.Params, Rejections: system.Rejections}
+		if err := json.Unmarshal(record.Value, &systemRecord); err != nil {
+			return err
+		}
+		system.Seed, system.Params = systemRecord.Seed, systemRecord.Params
+		system.BadAcceptCount, system.BadRejectCount = systemRecord.BadAcceptCount, systemRecord.BadRejectCount
+		system.FractalCounter, system.Network, system.Gossip = systemRecord.FractalCounter, systemRecord.Network, systemRecord.Gossip
+		system.Rejections, system.Beacon = systemRecord.Rejections, systemRecord.Beacon
+		if systemRecord.SubmitCount != nil {
+			system.SubmitCount = systemRecord.SubmitCount
+		}
+		if systemRecord.AcceptedCount != nil {
+			system.AcceptedCount = systemRecord.AcceptedCount
+		}
+		if systemRecord.Behaviors != nil {
+			system.Behaviors = systemRecord.Behaviors
+		}
+		if systemRecord.Topology != nil {
+			system.topology = *systemRecord.Topology
+		}
+		system.Overlay = systemRecord.Overlay
+		checkpoint.Clock, checkpoint.Random, checkpoint.Retired = systemRecord.Clock, systemRecord.Random, systemRecord.Retired
+	case "trader":
+		trader := &pkg.Trader{}
+		if err := json.Unmarshal(record.Value, trader); err != nil {
+			return err
+		}
+		system.Traders[record.Key] = trader
+	case "coin":
+		var coin pkg.CoinTable
+		if err := json.Unmarshal(record.Value, &coin); err != nil {
+			return err
+		}
+		system.Coins[record.Key] = coin
+	case "fractal":
+		fractal := &pkg.FractalRing{}
+		if err := json.Unmarshal(record.Value, fractal); err != nil {
+			return err
+		}
+		system.Fractals[record.Key] = fractal
+	case "trader_state":
+		var state pkg.TraderState
+		if err := json.Unmarshal(record.Value, &state); err != nil {
+			return err
+		}
+		checkpoint.Traders[record.Key] = state
+	default:
+		return errors.New("unknown record type")
+	}
+	return nil
+}
+
+func readLegacySnapshot(r io.Reader) (*System, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, "", err
+	}
+
+	system := NewSystem(0, pkg.DefaultParams())
+	if _, ok := fields["traders"]; ok && fields["system"] != nil {
+		checkpoint := &Checkpoint{System: system}
+		if err := json.Unmarshal(data, checkpoint); err != nil {
+			return nil, "", err
+		} else if err := checkpoint.checkSampler(1); err != nil {
+			return nil, "", err
+		}
+		s, err := checkpoint.Restore()
+		return s, KindCheckpoint, err
+	}
+
+	if err := json.Unmarshal(data, system); err != nil {
+		return nil, "", err
+	}
+	return system, KindSnapshot, nil
+}
@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+)
+
+func TestResumeOldSamplerCheckpoint(t *testing.T) {
+	scenario := testScenario()
+	scenario.Faults = Faults{}
+	system, _, err := runTest(t, context.Background(), scenario)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	if err := system.WriteSnapshot(&buffer, KindCheckpoint); err != nil {
+		t.Fatal(err)
+	}
+	cooperations := 0
+	for _, trader := range system.Traders {
+		cooperations += len(trader.Data.Cooperations)
+	}
+	if cooperations == 0 {
+		t.Fatal("the run left no cooperation rings in flight")
+	}
+
+	if _, _, err := ReadSnapshot(bytes.NewReader(buffer.Bytes())); err != nil {
+		t.Errorf("current checkpoint: %v", err)
+	}
+	current := []byte(`"version":` + strconv.Itoa(SnapshotVersion))
+	old := bytes.Replace(buffer.Bytes(), current, []byte(`"version":2`), 1)
+	if _, _, err := ReadSnapshot(bytes.NewReader(old)); err == nil {
+		t.Error("a version 2 checkpoint with cooperation rings in flight was resumed")
+	}
+}
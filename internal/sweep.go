@@ -0,0 +1,426 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+const (
+	PointPending     = "pending"
+	PointRunning     = "running"
+	PointDone        = "done"
+	PointFailed      = "failed"
+	PointSkipped     = "skipped"
+	PointInterrupted = "interrupted"
+)
+
+type Axis struct {
+	Name   string    `json:"name"`
+	Values []float64 `json:"values,omitempty"`
+	From   float64   `json:"from,omitempty"`
+	To     float64   `json:"to,omitempty"`
+	Step   float64   `json:"step,omitempty"`
+}
+
+type Grid struct {
+	Name string `json:"name"`
+	Axes []Axis `json:"axes"`
+}
+
+type SweepSpec struct {
+	Base  Scenario `json:"base"`
+	Grids []Grid   `json:"grids"`
+}
+
+type Point struct {
+	Name     string
+	Scenario Scenario
+	Invalid  error
+}
+
+type PointStatus struct {
+	Status   string    `json:"status"`
+	Error    string    `json:"error,omitempty"`
+	Seed     uint64    `json:"seed,omitempty"`
+	Started  time.Time `json:"started,omitempty"`
+	Finished time.Time `json:"finished,omitempty"`
+	Scenario Scenario  `json:"scenario"`
+}
+
+type SweepOptions struct {
+	OutDir   string
+	Workers  int
+	Compress bool
+	Logging  LogOptions
+}
+
+type Manifest struct {
+	Points map[string]*PointStatus `json:"points"`
+
+	path   string
+	locker sync.Mutex
+}
+
+func LoadSweepSpec(filePath string) (SweepSpec, error) {
+	spec := SweepSpec{Base: DefaultScenario()}
+	if err := readConfig(filePath, &spec); err != nil {
+		return spec, err
+	}
+	if len(spec.Grids) == 0 {
+		return spec, errors.New("sweep spec has no grids")
+	}
+	return spec, nil
+}
+
+func (axis Axis) Points() ([]float64, error) {
+	if len(axis.Values) > 0 {
+		return axis.Values, nil
+	} else if axis.Step <= 0 || axis.To < axis.From {
+		return nil, fmt.Errorf("axis %s needs values or a from/to/step range", axis.Name)
+	}
+
+	var values []float64
+	for i := 0; ; i++ {
+		value := axis.From + float64(i)*axis.Step
+		if value > axis.To+axis.Step*1e-9 {
+			break
+		}
+		values = append(values, math.Round(value*1e9)/1e9)
+	}
+	return values, nil
+}
+
+// ofTraders tells the axes given in percent of the traders, which Points
+// applies after every other axis so they see the final number of traders.
+func ofTraders(name string) bool {
+	switch name {
+	case "random", "bad", "coalition", "grinder":
+		return true
+	}
+	return strings.HasPrefix(name, "behavior:")
+}
+
+func applyAxis(scenario *Scenario, name string, value float64) error {
+	percent := func(value float64) int {
+		return int(float64(scenario.Traders) * value / 100)
+	}
+
+	switch name {
+	case "random":
+		scenario.Randoms = percent(value)
+	case "bad":
+		scenario.Bads = percent(value)
+	case "alpha":
+		scenario.Params.BadBehavior = value / 100
+	case "team_size":
+		scenario.Params.VerificationMin, scenario.Params.VerificationMax = int(value), int(value)
+	case "traders":
+		scenario.Traders = int(value)
+	case "types":
+		scenario.Types = uint(value)
+	case "time":
+		scenario.Time = int(value)
+	case "seed":
+		scenario.Seed = uint64(value)
+	case "fractal_min":
+		scenario.Params.FractalMin = int(value)
+	case "fractal_max":
+		scenario.Params.FractalMax = int(value)
+	case "rounds":
+		scenario.Params.RoundsCount = int(value)
+	case "ban":
+		scenario.Params.BanCount = int(value)
+	case "coalition":
+		scenario.Behaviors.SetCount(pkg.BehaviorCoalition, percent(value))
+	case "grinder":
+		scenario.Behaviors.SetCount(pkg.BehaviorGrinder, percent(value))
+	case "sybils":
+		scenario.Sybils = int(value)
+	case "wallets":
+		scenario.Wallets = int(value)
+	case "grind_budget":
+		scenario.Params.GrindBudget = int(value)
+	case "beacon_teams":
+		scenario.Params.BeaconTeams = value != 0
+	case "latency":
+		scenario.Faults.Latency = int(value)
+	case "jitter":
+		scenario.Faults.Jitter = int(value)
+	case "drop":
+		scenario.Faults.Drop = value / 100
+	case "duplicate":
+		scenario.Faults.Duplicate = value / 100
+	case "reorder":
+		scenario.Faults.Reorder = value / 100
+	case "fanout":
+		scenario.Gossip.Fanout = int(value)
+	case "ttl":
+		scenario.Gossip.TTL = int(value)
+	case "pull":
+		scenario.Gossip.Pull = int(value)
+	case "topology":
+		if kinds := Topologies(); value >= 0 && int(value) < len(kinds) {
+			scenario.Topology.Kind = kinds[int(value)]
+		} else {
+			return fmt.Errorf("topology axis value %v is not one of 0 to %d", value, len(kinds)-1)
+		}
+	case "degree":
+		scenario.Topology.Degree = int(value)
+	case "rewire":
+		scenario.Topology.Rewire = value / 100
+	default:
+		behavior, ok := strings.CutPrefix(name, "behavior:")
+		if !ok {
+			return fmt.Errorf("unknown axis %s", name)
+		}
+		scenario.Behaviors.SetCount(behavior, percent(value))
+	}
+	return nil
+}
+
+func (spec SweepSpec) Points() ([]Point, error) {
+	var points []Point
+	seen := make(map[string]bool)
+	for _, grid := range spec.Grids {
+		values := make([][]float64, len(grid.Axes))
+		for i, axis := range grid.Axes {
+			v, err := axis.Points()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+
+		indexes := make([]int, len(grid.Axes))
+		for {
+			name, scenario := grid.Name, spec.Base
+			for _, percents := range []bool{false, true} {
+				for i, axis := range grid.Axes {
+					if ofTraders(axis.Name) != percents {
+						continue
+					} else if err := applyAxis(&scenario, axis.Name, values[i][indexes[i]]); err != nil {
+						return nil, err
+					}
+				}
+			}
+			for i, axis := range grid.Axes {
+				name = strings.ReplaceAll(name, "{"+axis.Name+"}", strconv.FormatFloat(values[i][indexes[i]], 'f', -1, 64))
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("duplicate point name %s", name)
+			}
+			seen[name] = true
+			points = append(points, Point{Name: name, Scenario: scenario, Invalid: scenario.Validate()})
+
+			i := len(indexes) - 1
+			for ; i >= 0; i-- {
+				if indexes[i]++; indexes[i] < len(values[i]) {
+					break
+				}
+				indexes[i] = 0
+			}
+			if i < 0 {
+				break
+			}
+		}
+	}
+	return points, nil
+}
+
+func LoadManifest(filePath string) (*Manifest, error) {
+	manifest := &Manifest{Points: make(map[string]*PointStatus), path: filePath}
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func (manifest *Manifest) update(name string, change func(status *PointStatus)) error {
+	manifest.locker.Lock()
+	defer manifest.locker.Unlock()
+
+	status, ok := manifest.Points[name]
+	if !ok {
+		status = &PointStatus{Status: PointPending}
+		manifest.Points[name] = status
+	}
+	change(status)
+	return manifest.save()
+}
+
+func (manifest *Manifest) save() error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := manifest.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, manifest.path)
+}
+
+func (options SweepOptions) snapshotPath(point Point) string {
+	if options.Compress {
+		return filepath.Join(options.OutDir, point.Name+".json.gz")
+	}
+	return filepath.Join(options.OutDir, point.Name+".json")
+}
+
+func (manifest *Manifest) isDone(point Point, snapshotPath string) bool {
+	manifest.locker.Lock()
+	defer manifest.locker.Unlock()
+
+	status, ok := manifest.Points[point.Name]
+	if !ok || status.Status != PointDone || !reflect.DeepEqual(status.Scenario, point.Scenario) {
+		return false
+	}
+	_, err := os.Stat(snapshotPath)
+	return err == nil
+}
+
+func RunSweep(ctx context.Context, spec SweepSpec, options SweepOptions, logger *slog.Logger) (*Manifest, error) {
+	logger = logger.With("component", ComponentSweep)
+	points, err := spec.Points()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(options.OutDir, 0755); err != nil {
+		return nil, err
+	}
+	manifest, err := LoadManifest(filepath.Join(options.OutDir, "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	queue := make(chan Point)
+	var wg sync.WaitGroup
+	for i := 0; i < max(options.Workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for point := range queue {
+				runPoint(ctx, point, options, manifest, logger)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(queue)
+		for _, point := range points {
+			if manifest.isDone(point, options.snapshotPath(point)) {
+				logger.Info("skipping point, already done", "point", point.Name)
+				continue
+			} else if point.Invalid != nil {
+				if err := manifest.update(point.Name, func(status *PointStatus) {
+					status.Status, status.Error, status.Scenario = PointSkipped, point.Invalid.Error(), point.Scenario
+				}); err != nil {
+					logger.Error("error updating manifest", "point", point.Name, "error", err)
+				}
+				continue
+			}
+
+			select {
+			case queue <- point:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Running points stop soon after ctx is cancelled and mark themselves
+	// interrupted.
+	wg.Wait()
+	return manifest, ctx.Err()
+}
+
+func runPoint(ctx context.Context, point Point, options SweepOptions, manifest *Manifest, logger *slog.Logger) {
+	scenario := point.Scenario
+	if scenario.Seed == 0 {
+		scenario.Seed = uint64(time.Now().UnixNano())
+	}
+
+	if err := manifest.update(point.Name, func(status *PointStatus) {
+		*status = PointStatus{Status: PointRunning, Seed: scenario.Seed, Started: time.Now(), Scenario: point.Scenario}
+	}); err != nil {
+		logger.Error("error updating manifest", "point", point.Name, "error", err)
+	}
+	logger.Info("running point", "point", point.Name, "seed", scenario.Seed)
+
+	err := runScenarioTo(ctx, scenario, filepath.Join(options.OutDir, point.Name), options.snapshotPath(point), options.Logging)
+	if err := manifest.update(point.Name, func(status *PointStatus) {
+		status.Finished = time.Now()
+		if errors.Is(err, context.Canceled) {
+			status.Status, status.Error = PointInterrupted, ""
+		} else if err != nil {
+			status.Status, status.Error = PointFailed, err.Error()
+		} else {
+			status.Status, status.Error = PointDone, ""
+		}
+	}); err != nil {
+		logger.Error("error updating manifest", "point", point.Name, "error", err)
+	}
+
+	if errors.Is(err, context.Canceled) {
+		logger.Warn("point interrupted", "point", point.Name)
+	} else if err != nil {
+		logger.Error("point failed", "point", point.Name, "error", err)
+	} else {
+		logger.Info("point finished", "point", point.Name)
+	}
+}
+
+func runScenarioTo(ctx context.Context, scenario Scenario, prefix, snapshotPath string, logging LogOptions) (err error) {
+	logFile, err := os.Create(prefix + ".log")
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	system, err := scenario.Run(ctx, NewLogger(logFile, logging), nil)
+	if err != nil {
+		return err
+	}
+
+	resultFile, err := os.Create(prefix + ".result")
+	if err != nil {
+		return err
+	}
+	defer resultFile.Close()
+	if err := Analyze(system).WriteText(resultFile); err != nil {
+		return err
+	}
+
+	if scenario.Sample > 0 {
+		if err := system.SaveSamples(SamplesPath(snapshotPath)); err != nil {
+			return err
+		}
+	}
+	return system.Save(snapshotPath)
+}
@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+func TestPointsPercentAxes(t *testing.T) {
+	spec := SweepSpec{Base: DefaultScenario(), Grids: []Grid{{
+		Name: "t{traders}",
+		Axes: []Axis{
+			{Name: "bad", Values: []float64{10}},
+			{Name: "coalition", Values: []float64{20}},
+			{Name: "traders", Values: []float64{50, 200}},
+		},
+	}}}
+	points, err := spec.Points()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, point := range points {
+		traders := point.Scenario.Traders
+		if point.Scenario.Bads != traders/10 {
+			t.Errorf("%s: %d bad traders, want %d", point.Name, point.Scenario.Bads, traders/10)
+		}
+		for _, group := range point.Scenario.Behaviors {
+			if group.Behavior == pkg.BehaviorCoalition && group.Count != traders/5 {
+				t.Errorf("%s: %d coalition traders, want %d", point.Name, group.Count, traders/5)
+			}
+		}
+	}
+}
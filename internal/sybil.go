@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+type PrincipalMetrics struct {
+	Principal       string `json:"principal"`
+	Wallets         int    `json:"wallets"`
+	Share           Ratio  `json:"share"`
+	Submitted       int    `json:"submitted"`
+	Accepted        int    `json:"accepted"`
+	BadAccepted     int    `json:"bad_accept_count"`
+	CapturedTeams   int    `json:"captured_teams"`
+	CaptureRate     Ratio  `json:"capture_rate"`
+	ExpectedCapture Ratio  `json:"expected_capture"`
+}
+
+func principalOf(system *System, traderID string) string {
+	if name := system.Behaviors[traderID]; name != "" {
+		if base, _ := pkg.SplitBehavior(name); base == pkg.BehaviorSybil {
+			return name
+		}
+	}
+	return traderID
+}
+
+func analyzePrincipals(system *System, metrics *Metrics) {
+	principals := make(map[string]*PrincipalMetrics)
+	for traderID := range system.Traders {
+		name := principalOf(system, traderID)
+		principal, ok := principals[name]
+		if !ok {
+			principal = &PrincipalMetrics{Principal: name}
+			principals[name] = principal
+		}
+		principal.Wallets++
+		principal.Submitted += system.SubmitCount[traderID]
+		principal.Accepted += system.AcceptedCount[traderID]
+	}
+
+	for _, fractal := range system.Fractals {
+		seats := make(map[string]int)
+		for _, traderID := range fractal.VerificationTeam {
+			seats[principalOf(system, traderID)]++
+		}
+		for name, count := range seats {
+			if 2*count >= len(fractal.VerificationTeam) {
+				principals[name].CapturedTeams++
+			}
+		}
+		if submitter, ok := principals[principalOf(system, fractal.Submitter)]; ok && !fractal.IsValid {
+			submitter.BadAccepted++
+		}
+	}
+
+	for _, name := range sortedKeys(principals) {
+		principal := principals[name]
+		if principal.Wallets < 2 {
+			continue
+		}
+		principal.Share = Ratio(float64(principal.Wallets) / float64(len(system.Traders)))
+		principal.CaptureRate = Ratio(float64(principal.CapturedTeams) / float64(len(system.Fractals)))
+		principal.ExpectedCapture = Ratio(CaptureProbability(len(system.Traders), principal.Wallets, system.Params.VerificationMin, system.Params.VerificationMax))
+		metrics.Principals = append(metrics.Principals, *principal)
+	}
+	if len(metrics.Principals) > 0 {
+		metrics.MajorityWallets = MinimumWallets(len(system.Traders), system.Params.VerificationMin, system.Params.VerificationMax, 0.5)
+	}
+}
+
+// CaptureProbability is the chance that a principal with the given number of
+// wallets holds at least half of a uniformly drawn verification team, which
+// is enough for verifyFractal to accept whatever its wallets approve.
+func CaptureProbability(traders, wallets, teamMin, teamMax int) float64 {
+	total, sizes := 0.0, 0
+	for k := teamMin; k <= teamMax; k++ {
+		sizes++
+		if k > traders {
+			continue
+		}
+		for seats := (k + 1) / 2; seats <= min(k, wallets); seats++ {
+			total += math.Exp(logChoose(wallets, seats) + logChoose(traders-wallets, k-seats) - logChoose(traders, k))
+		}
+	}
+	return total / float64(sizes)
+}
+
+func MinimumWallets(traders, teamMin, teamMax int, target float64) int {
+	for wallets := 0; wallets <= traders; wallets++ {
+		if CaptureProbability(traders, wallets, teamMin, teamMax) >= target-1e-9 {
+			return wallets
+		}
+	}
+	return -1
+}
+
+func logChoose(n, k int) float64 {
+	if k < 0 || k > n {
+		return math.Inf(-1)
+	}
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+func (principal PrincipalMetrics) String() string {
+	return fmt.Sprintf("Principal %s: %d wallets (%.2f%% of traders), %d submitted, %d accepted, %d invalid accepted, %d captured teams (%.2f%% of fractal rings, %.2f%% expected)",
+		principal.Principal, principal.Wallets, principal.Share*100, principal.Submitted, principal.Accepted, principal.BadAccepted,
+		principal.CapturedTeams, principal.CaptureRate*100, principal.ExpectedCapture*100)
+}
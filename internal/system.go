@@ -1,115 +1,177 @@
 package internal
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"log"
-	"math/rand"
-	"os"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 	"sync"
-	"syscall"
 	"time"
 
 	"github.com/Arka-Lab/LoR/pkg"
+	"github.com/Arka-Lab/LoR/tools"
 	"github.com/google/uuid"
+	"golang.org/x/exp/maps"
 )
 
-const (
-	Debug       = false
-	RunFractals = true
+const RunFractals = true
+
+var (
+	ErrVerificationFailed = errors.New("fractal ring verification failed")
+	ErrNotSubmitted       = errors.New("fractal ring not submitted")
+	ErrFractalNotFound    = errors.New("fractal ring not found")
 )
 
 type System struct {
+	Seed           uint64
+	Params         pkg.Params
 	BadAcceptCount int
 	BadRejectCount int
 	FractalCounter int
-	Locker         sync.Mutex
 	SubmitCount    map[string]int
 	AcceptedCount  map[string]int
 	Traders        map[string]*pkg.Trader
 	Coins          map[string]pkg.CoinTable
 	Fractals       map[string]*pkg.FractalRing
+	Behaviors      map[string]string
+	Network        NetworkStats
+	Gossip         GossipStats
+	Rejections     RejectionStats
+	Overlay        map[string][]string
+	Beacon         string
+
+	clock     *tools.Scheduler
+	society   *pkg.Society
+	random    *tools.Random
+	loggers   map[string]*slog.Logger
+	samples   []Sample
+	journal   *Journal
+	network   Network
+	faults    Faults
+	gossip    Gossip
+	topology  Topology
+	proposals map[string]bool
+	bans      map[string]int
+	errors    ErrorSummary
+	retired   map[string]bool
+	stopped   bool
+
+	// mutex is held by the coordinator while it changes the system, so that
+	// Inspect can read it between two clock events.
+	mutex sync.Mutex
 }
 
-func NewSystem() *System {
+func NewSystem(seed uint64, params pkg.Params) *System {
 	return &System{
+		Seed:           seed,
+		Params:         params,
 		BadAcceptCount: 0,
 		BadRejectCount: 0,
 		FractalCounter: 0,
-		Locker:         sync.Mutex{},
 		SubmitCount:    make(map[string]int),
 		AcceptedCount:  make(map[string]int),
 		Traders:        make(map[string]*pkg.Trader),
 		Coins:          make(map[string]pkg.CoinTable),
 		Fractals:       make(map[string]*pkg.FractalRing),
+		Behaviors:      make(map[string]string),
+		Rejections:     RejectionStats{Coins: make(ErrorSummary), Votes: make(ErrorSummary), Fractals: make(ErrorSummary)},
+		society:        pkg.NewSociety(),
+		clock:          tools.NewScheduler(0),
+		random:         tools.NewRandom(seed),
+		proposals:      make(map[string]bool),
+		bans:           make(map[string]int),
+		retired:        make(map[string]bool),
 	}
 }
 
-func (system *System) ProcessCoin(coin pkg.CoinTable) error {
-	system.Locker.Lock()
-	defer system.Locker.Unlock()
+// SetLogger gives every component of the system a logger tagged with its
+// name. Systems without one log to slog.Default().
+func (system *System) SetLogger(logger *slog.Logger) {
+	system.loggers = make(map[string]*slog.Logger)
+	for _, component := range Components() {
+		system.loggers[component] = logger.With("component", component)
+	}
+}
 
-	system.Coins[coin.ID] = coin
-	if err := system.saveCoinToTraders(coin); err != nil {
-		return err
+func (system *System) log(component string) *slog.Logger {
+	if system.loggers == nil {
+		system.SetLogger(slog.Default())
 	}
+	return system.loggers[component]
+}
 
-	return system.processTradersForCoin(coin)
+func (system *System) Now() time.Duration {
+	return system.clock.Now()
 }
 
-func (system *System) saveCoinToTraders(coin pkg.CoinTable) error {
-	for _, t := range system.Traders {
-		if err := t.SaveCoin(coin); err != nil {
-			return err
-		}
-	}
-	return nil
+func (system *System) traderIDs() []string {
+	traderIDs := maps.Keys(system.Traders)
+	slices.Sort(traderIDs)
+	return traderIDs
 }
 
-func (system *System) processTradersForCoin(coin pkg.CoinTable) error {
-	for index, traderID := range system.getShuffledTraderIDs(coin.Owner) {
-		trader := system.Traders[traderID]
-		if fractal := trader.CheckForRings(system.FractalCounter); fractal != nil {
-			system.FractalCounter++
-			system.SubmitCount[traderID]++
-			if err := system.handleFractal(trader, fractal, index); err != nil {
-				return err
+// timer sends body from a node to itself after delay. Mint and pull timers
+// stop firing once the run time is over.
+func (system *System) timer(nodeID string, delay time.Duration, body Body) {
+	system.clock.After(delay, func() {
+		switch body.(type) {
+		case Mint, Pull:
+			if system.stopped {
+				return
 			}
-			return nil
 		}
-	}
-	return nil
+		system.network.Send(nodeID, nodeID, body)
+	})
 }
 
-func (system *System) handleFractal(trader *pkg.Trader, fractal *pkg.FractalRing, index int) error {
-	if err := system.processFractal(trader, fractal); err != nil {
-		if fractal.IsValid {
-			system.BadRejectCount++
+// handle runs the ledger node on the coordinator: it keeps the global coin
+// table, counts submissions and verdicts, bans minorities and settles
+// cooperation rings. It owns the system's state, so it acts directly instead
+// of leaving effects.
+func (system *System) handle(message Message) []Effect {
+	switch body := message.Body.(type) {
+	case CoinAnnouncement:
+		system.recordCoin(body.Coin)
+	case Retired:
+		system.retired[message.From] = true
+	case FractalProposal:
+		system.proposals[body.Fractal.ID] = true
+		system.FractalCounter++
+		system.SubmitCount[message.From]++
+		system.emit(FractalSubmitted{TraderID: message.From, Fractal: body.Fractal})
+	case Verdict:
+		system.judge(message.From, body)
+	case RoundResult:
+		if err := system.closeRound(body); err != nil {
+			system.reportError(err)
 		}
-		return err
-	}
-
-	if !fractal.IsValid {
-		system.BadAcceptCount++
-	}
-	if Debug {
-		log.Printf("Fractal ring created by trader %d with %d cooperation rings and %d verification team members\n", index+1, len(fractal.CooperationRings), len(fractal.VerificationTeam))
-	}
-	if RunFractals {
-		if err := system.runFractal(fractal); err != nil {
-			return err
+	case FractalFinished:
+		if err := system.finishFractal(body.FractalID); err != nil {
+			system.reportError(err)
 		}
 	}
 	return nil
 }
 
+func (system *System) recordCoin(coin pkg.CoinTable) {
+	system.Coins[coin.ID] = coin
+	system.emit(CoinCreated{Coin: coin})
+	system.emit(CoinSaved{CoinID: coin.ID})
+
+	if order := system.getShuffledTraderIDs(coin.Owner); len(order) > 0 {
+		system.network.Send(LedgerID, order[0], RingCheck{CoinID: coin.ID, Order: order[1:], FractalCounter: system.FractalCounter})
+	}
+}
+
 func (system *System) getShuffledTraderIDs(firstID string) (result []string) {
-	for traderID := range system.Traders {
+	for _, traderID := range system.traderIDs() {
 		if traderID != firstID {
 			result = append(result, traderID)
 		}
 	}
-	rand.Shuffle(len(result), func(i, j int) {
+	system.random.Shuffle(len(result), func(i, j int) {
 		result[i], result[j] = result[j], result[i]
 	})
 
@@ -119,134 +181,131 @@ func (system *System) getShuffledTraderIDs(firstID string) (result []string) {
 	return
 }
 
-func (system *System) processFractal(trader *pkg.Trader, fractal *pkg.FractalRing) error {
-	if err := system.verifyFractal(fractal); err != nil {
-		trader.RemoveFractalRing(fractal.ID)
-		return err
-	} else if err := system.checkCoins(fractal); err != nil {
-		return err
-	} else if err := system.informOthers(fractal); err != nil {
-		return err
-	}
-	system.Fractals[fractal.ID] = fractal
-	system.AcceptedCount[trader.ID]++
-	return nil
-}
+func (system *System) judge(submitter string, verdict Verdict) {
+	fractal := verdict.Fractal
+	if !system.proposals[fractal.ID] {
+		system.network.Send(LedgerID, submitter, RejectedFractal{FractalID: fractal.ID, Reason: ErrNotSubmitted.Error()})
+		return
+	}
+	delete(system.proposals, fractal.ID)
 
-func (system *System) verifyFractal(fractal *pkg.FractalRing) error {
-	accepted, rejected := []string{}, []string{}
-	for _, traderID := range fractal.VerificationTeam {
-		if err := system.Traders[traderID].SubmitRing(fractal); err != nil {
-			rejected = append(rejected, traderID)
-		} else {
-			accepted = append(accepted, traderID)
+	elapsed := system.clock.Now() - verdict.Proposed
+	system.Network.Verdicts++
+	system.Network.VerdictTime += elapsed
+	system.Network.MaxVerdictTime = max(system.Network.MaxVerdictTime, elapsed)
+
+	system.banTraders(verdict.Accepted, verdict.Rejected)
+	if rejectedBy(verdict.Accepted, verdict.Rejected, verdict.Absent) {
+		system.rejectFractal(submitter, fractal, &pkg.ProtocolError{Err: ErrVerificationFailed, Subject: "fractal ring", ID: fractal.ID})
+		return
+	} else if err := system.checkCoins(&fractal); err != nil {
+		system.Network.CheckCoinsFailures++
+		system.rejectFractal(submitter, fractal, err)
+		return
+	}
+
+	for _, ring := range fractal.CooperationRings {
+		for _, coinID := range ring.CoinIDs {
+			coin := system.Coins[coinID]
+			coin.Status = pkg.Blocked
+			system.Coins[coinID] = coin
 		}
 	}
+	fractal.CooperationRings = slices.Clone(fractal.CooperationRings)
+	system.Fractals[fractal.ID] = &fractal
+	system.AcceptedCount[submitter]++
+	if !fractal.IsValid {
+		system.BadAcceptCount++
+	}
+	system.Beacon = pkg.NextBeacon(system.Beacon, fractal.ID)
+	system.emit(FractalAccepted{FractalID: fractal.ID, TraderID: submitter})
+	system.log(ComponentLedger).Debug("fractal ring accepted", "fractal_id", fractal.ID, "trader_id", submitter,
+		"rings", len(fractal.CooperationRings), "team", len(fractal.VerificationTeam), "at", system.clock.Now())
+	system.network.Broadcast(LedgerID, AcceptedFractal{Fractal: verdict.Fractal, Beacon: system.Beacon, Sequence: len(system.Fractals)})
+}
 
-	system.banTraders(accepted, rejected)
-	if len(rejected) > len(accepted) {
-		return errors.New("fractal ring verification failed")
+func (system *System) rejectFractal(submitter string, fractal pkg.FractalRing, err error) {
+	reason := pkg.Reason(err)
+	system.emit(FractalRejected{FractalID: fractal.ID, TraderID: submitter, Reason: reason})
+	system.log(ComponentLedger).Debug("fractal ring rejected", "fractal_id", fractal.ID, "trader_id", submitter, "reason", reason, "at", system.clock.Now())
+	system.Rejections.Fractals[reason]++
+	if fractal.IsValid {
+		system.BadRejectCount++
 	}
-	return nil
+	system.network.Send(LedgerID, submitter, RejectedFractal{FractalID: fractal.ID, Reason: reason})
+	system.reportError(err)
 }
 
 func (system *System) checkCoins(fractal *pkg.FractalRing) error {
 	for _, ring := range fractal.CooperationRings {
 		for _, coinID := range ring.CoinIDs {
 			if coin, ok := system.Coins[coinID]; !ok {
-				return errors.New("coin not found")
+				return &pkg.ProtocolError{Err: pkg.ErrCoinNotFound, Subject: "coin", ID: coinID}
 			} else if coin.Status != pkg.Run {
-				return errors.New("coin is not running")
+				return &pkg.ProtocolError{Err: pkg.ErrCoinNotRunning, Subject: "coin", ID: coinID}
 			}
 		}
 	}
 	return nil
 }
 
-func (system *System) informOthers(fractal *pkg.FractalRing) error {
-	for _, ring := range fractal.CooperationRings {
-		for _, coinID := range ring.CoinIDs {
-			coin := system.Coins[coinID]
-			coin.Status = pkg.Blocked
-			system.Coins[coinID] = coin
-		}
+func (system *System) closeRound(result RoundResult) error {
+	system.emit(RoundVote{FractalID: result.FractalID, Ring: result.Ring, Round: result.Round, Accepted: len(result.Accepted), Rejected: len(result.Rejected)})
+	system.banTraders(result.Accepted, result.Rejected)
+	if !rejectedBy(result.Accepted, result.Rejected, result.Absent) {
+		return nil
 	}
-	for _, trader := range system.Traders {
-		if err := trader.InformFractalRing(*fractal); err != nil {
-			return err
-		}
+
+	fractal, ok := system.Fractals[result.FractalID]
+	if !ok || result.Ring < 0 || result.Ring >= len(fractal.CooperationRings) {
+		return &pkg.ProtocolError{Err: ErrFractalNotFound, Subject: "fractal ring", ID: result.FractalID}
 	}
+	ring := fractal.CooperationRings[result.Ring]
+	if ring.Rounds != -1 {
+		return nil
+	}
+	ring.Rounds = result.Round
+	fractal.CooperationRings[result.Ring] = ring
+	system.log(ComponentLedger).Debug("cooperation ring voted out", "fractal_id", fractal.ID, "ring", result.Ring, "round", result.Round, "at", system.clock.Now())
+	money := system.Coins[ring.CoinIDs[0]].Amount * float64(result.Round) / float64(system.Params.RoundsCount)
+	system.emit(RingApplied{FractalID: fractal.ID, Ring: result.Ring, Rounds: result.Round, Money: money})
+	system.applyRing(ring, money)
 	return nil
 }
 
-func (system *System) runFractal(fractal *pkg.FractalRing) error {
-	for round := 0; round < pkg.RoundsCount; round++ {
-		for index, ring := range fractal.CooperationRings {
-			if ring.Rounds == -1 {
-				accepted, rejected := []string{}, []string{}
-				for _, traderID := range fractal.VerificationTeam {
-					if err := system.Traders[traderID].Vote(); err != nil {
-						if err.Error() != "bad behavior" {
-							return err
-						}
-						rejected = append(rejected, traderID)
-					} else {
-						accepted = append(accepted, traderID)
-					}
-				}
-
-				system.banTraders(accepted, rejected)
-				if len(rejected) > len(accepted) {
-					ring.Rounds = round
-					fractal.CooperationRings[index] = ring
-					money := system.Coins[ring.CoinIDs[0]].Amount * float64(round) / pkg.RoundsCount
-					if err := system.applyRing(ring, money); err != nil {
-						return err
-					}
-				}
-			}
-		}
+func (system *System) finishFractal(fractalID string) error {
+	fractal, ok := system.Fractals[fractalID]
+	if !ok {
+		return &pkg.ProtocolError{Err: ErrFractalNotFound, Subject: "fractal ring", ID: fractalID}
 	}
-
 	for index, ring := range fractal.CooperationRings {
 		if ring.Rounds == -1 {
-			ring.Rounds = pkg.RoundsCount
+			ring.Rounds = system.Params.RoundsCount
 			fractal.CooperationRings[index] = ring
-			if err := system.applyRing(ring, system.Coins[ring.CoinIDs[0]].Amount); err != nil {
-				return err
-			}
+			money := system.Coins[ring.CoinIDs[0]].Amount
+			system.emit(RingApplied{FractalID: fractal.ID, Ring: index, Rounds: ring.Rounds, Money: money})
+			system.applyRing(ring, money)
 		}
 	}
 	return nil
 }
 
-func (system *System) applyRing(ring pkg.CooperationTable, money float64) error {
+func (system *System) applyRing(ring pkg.CooperationTable, money float64) {
+	changes := make([]BalanceChange, 0, len(ring.CoinIDs))
 	for _, coinID := range ring.CoinIDs {
 		coin := system.Coins[coinID]
 		amount := money * coin.Amount / ring.Weight
-		if ring.Rounds < pkg.RoundsCount {
+		if ring.Rounds < system.Params.RoundsCount {
 			coin.Status = pkg.Expired
 		} else {
 			coin.Status = pkg.Paid
-			amount += pkg.FractalPrize
+			amount += system.Params.FractalPrize
 		}
 		system.Coins[coinID] = coin
-
-		for _, trader := range system.Traders {
-			if err := trader.UpdateBalance(coin.Owner, amount); err != nil {
-				return err
-			}
-		}
-	}
-
-	for _, trader := range system.Traders {
-		if ring.Rounds < pkg.RoundsCount {
-			trader.ExpireRing(ring)
-		} else {
-			trader.PayRing(ring)
-		}
+		system.emit(BalanceUpdated{TraderID: coin.Owner, CoinID: coinID, Amount: amount})
+		changes = append(changes, BalanceChange{Owner: coin.Owner, Amount: amount})
 	}
-	return nil
+	system.network.Broadcast(LedgerID, RingClosed{Ring: ring, Changes: changes})
 }
 
 func (system *System) banTraders(accepted, rejected []string) {
@@ -255,144 +314,182 @@ func (system *System) banTraders(accepted, rejected []string) {
 		minority = rejected
 	}
 	for _, traderID := range minority {
-		system.Traders[traderID].Data.BanUntil = system.FractalCounter + pkg.BanCount
+		until := system.FractalCounter + system.Params.BanCount
+		system.bans[traderID] = until
+		system.emit(TraderBanned{TraderID: traderID, Until: until})
+		system.log(ComponentLedger).Debug("trader banned", "trader_id", traderID, "until", until, "at", system.clock.Now())
+		system.network.Send(LedgerID, traderID, Ban{Until: until})
 	}
 }
 
-func (system *System) CreateRandomCoins(trader *pkg.Trader, done <-chan bool, errors chan<- error) {
-	for {
-		select {
-		case <-done:
-			return
-		case <-trader.Data.Ticker.C:
-			amount := rand.Float64() * 10
-			if trader.Account < amount {
-				return
-			}
-
-			coinType := rand.Intn(int(trader.Data.CoinTypeCount))
-			if coin := trader.CreateCoin(amount, uint(coinType)); coin != nil {
-				if err := system.ProcessCoin(*coin); err != nil {
-					errors <- err
-				}
-			}
-		}
-	}
+// RejectionStats counts why coins were refused by traders, why verifiers
+// voted against fractal rings and why the ledger rejected them.
+type RejectionStats struct {
+	Coins    ErrorSummary `json:"coins,omitempty"`
+	Votes    ErrorSummary `json:"votes,omitempty"`
+	Fractals ErrorSummary `json:"fractals,omitempty"`
 }
 
-func (system *System) Init(numTraders, numRandomVoters, numBadVoters int, coinTypeCount uint) error {
-	ch := make(chan bool)
-	for i := 0; i < numTraders; i++ {
-		go func() {
-			var trader *pkg.Trader
-			amount, wallet := rand.Float64()*1000, uuid.New().String()
-			if i < numRandomVoters {
-				trader = pkg.CreateTrader(pkg.RandomVote, amount, wallet, coinTypeCount)
-			} else if i < numRandomVoters+numBadVoters {
-				trader = pkg.CreateTrader(pkg.BadVote, amount, wallet, coinTypeCount)
-			} else {
-				trader = pkg.CreateTrader(pkg.Normal, amount, wallet, coinTypeCount)
-			}
+// ErrorSummary counts the errors of a run by message.
+type ErrorSummary map[string]int
 
-			system.Locker.Lock()
-			defer system.Locker.Unlock()
-			system.Traders[trader.ID] = trader
-			ch <- true
-		}()
+func (summary ErrorSummary) Total() (total int) {
+	for _, count := range summary {
+		total += count
 	}
-	log.Printf("%d traders created: %d random voters, %d bad voters\n", numTraders, numRandomVoters, numBadVoters)
-	for i := 0; i < numTraders; i++ {
-		<-ch
+	return
+}
+
+// String lists the errors from the most to the least frequent.
+func (summary ErrorSummary) String() string {
+	messages := sortedKeys(summary)
+	slices.SortStableFunc(messages, func(a, b string) int {
+		return summary[b] - summary[a]
+	})
+	parts := make([]string, len(messages))
+	for i, message := range messages {
+		parts[i] = fmt.Sprintf("%d %s", summary[message], message)
 	}
-	return system.saveTraders()
+	return strings.Join(parts, ", ")
 }
 
-func (system *System) saveTraders() error {
-	for _, trader1 := range system.Traders {
-		for _, trader2 := range system.Traders {
-			if err := trader1.SaveTrader(*trader2); err != nil {
-				return err
-			}
-		}
+func (system *System) reportError(err error) {
+	if system.errors != nil {
+		system.errors[pkg.Reason(err)]++
 	}
-	return nil
+	system.log(ComponentSystem).Debug("protocol error", "error", err, "at", system.clock.Now())
 }
 
-func (system *System) Start(finish <-chan bool) {
-	errors := make(chan error)
-	dones := make([]chan bool, len(system.Traders))
-
-	i, finished := 0, 0
-	for _, trader := range system.Traders {
-		dones[i] = make(chan bool, 1)
-		go func(index int) {
-			system.CreateRandomCoins(trader, dones[index], errors)
-			finished++
-		}(i)
-		i++
-	}
-
-	for finished < len(system.Traders) {
-		select {
-		case err := <-errors:
-			if Debug {
-				log.Println("Error:", err)
-				if err.Error() != "bad behavior" {
-					syscall.Exit(1)
-				}
-			}
-		case <-finish:
-			i := 0
-			for _, trader := range system.Traders {
-				trader.Data.Ticker.Stop()
-				dones[i] <- true
-				i++
-			}
+func (system *System) Init(behaviors []string, coinTypeCount uint) error {
+	started := SimulationStarted{Seed: system.Seed, Params: system.Params}
+	if !system.topology.Complete() {
+		started.Topology = &system.topology
+	}
+	system.emit(started)
 
-			log.Println("Waiting for traders to finish...")
-			time.Sleep(10 * time.Second)
-			return
+	numTraders, counts := len(behaviors), make(map[string]int)
+	ch := make(chan *pkg.Trader)
+	for _, name := range behaviors {
+		behavior, err := pkg.NewBehavior(name, system.society)
+		if err != nil {
+			return err
+		}
+		counts[name]++
+
+		amount := system.random.Float64() * 1000
+		wallet, err := uuid.NewRandomFromReader(system.random)
+		if err != nil {
+			return err
 		}
+		random := system.random.Child()
+
+		go func() {
+			ch <- pkg.CreateTrader(system.Params, behavior, amount, wallet.String(), coinTypeCount, random)
+		}()
 	}
-}
 
-func (system *System) Save(filePath string) error {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return err
+	summary := make([]string, 0, len(counts))
+	for _, name := range sortedKeys(counts) {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[name], name))
 	}
-	defer file.Close()
+	system.log(ComponentSystem).Info("creating traders", "traders", numTraders, "behaviors", strings.Join(summary, ", "))
 
-	data, err := json.Marshal(system)
-	if err != nil {
-		return err
+	failed := 0
+	for i := 0; i < numTraders; i++ {
+		if trader := <-ch; trader == nil {
+			failed++
+		} else {
+			system.Traders[trader.ID] = trader
+		}
 	}
+	if failed > 0 {
+		return fmt.Errorf("failed to create %d traders", failed)
+	}
+	system.joinBehaviors()
+	system.connect()
+	for _, traderID := range system.traderIDs() {
+		system.emit(TraderCreated{Trader: *system.Traders[traderID], Behavior: system.Behaviors[traderID], Neighbors: system.Overlay[traderID]})
+	}
+	return system.saveTraders()
+}
 
-	_, err = file.Write(data)
-	return err
+func (system *System) joinBehaviors() {
+	for _, traderID := range system.traderIDs() {
+		behavior := system.Traders[traderID].Data.Behavior
+		system.Behaviors[traderID] = behavior.Name()
+		if member, ok := behavior.(pkg.Member); ok {
+			member.Join(system.Traders[traderID])
+		}
+	}
 }
 
-func Load(filePath string) (*System, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return nil, err
+// saveTraders introduces every trader to the others, or only to itself and
+// its neighbors in a partial-view topology.
+func (system *System) saveTraders() error {
+	traderIDs := system.traderIDs()
+	for traderID, trader1 := range system.Traders {
+		known := traderIDs
+		if system.Overlay != nil {
+			known = append(slices.Clone(system.Overlay[traderID]), traderID)
+		}
+		for _, knownID := range known {
+			if err := trader1.SaveTrader(*system.Traders[knownID]); err != nil {
+				return err
+			}
+		}
 	}
-	defer file.Close()
+	return nil
+}
 
-	fileStatus, err := file.Stat()
-	if err != nil {
-		return nil, err
+// Start runs the simulation for runTime of virtual time, then stops minting
+// and drains the fractal rings still in flight, which only depends on the
+// state the run stopped in. Cancelling ctx stops the run early the same way.
+// Start returns once every node goroutine has exited, with the errors the run
+// ran into and ctx.Err() if it was cancelled.
+func (system *System) Start(ctx context.Context, runTime time.Duration) (ErrorSummary, error) {
+	system.mutex.Lock()
+	system.stopped = false
+	system.errors = make(ErrorSummary)
+	system.Gossip.Fanout = system.gossip.Fanout
+	network := NewLocalNetwork(system.clock, system.random, system.faults, &system.Network)
+	system.network = network
+	network.Host(LedgerID, deduplicate(system.handle))
+	for _, traderID := range system.traderIDs() {
+		network.Subscribe(traderID, deduplicate(NewNode(system, system.Traders[traderID]).handle))
+		if !system.retired[traderID] {
+			system.timer(traderID, system.Params.RoundDuration(), Mint{})
+		}
+		if system.gossip.Pull > 0 {
+			system.timer(traderID, time.Duration(system.gossip.Pull)*time.Millisecond, Pull{})
+		}
 	}
+	defer network.Close()
+	system.schedulePartitions(network)
+	system.mutex.Unlock()
 
-	data := make([]byte, fileStatus.Size())
-	n, err := file.Read(data)
-	if err != nil {
-		return nil, err
+	end := system.clock.Now() + runTime
+	for running := true; running && ctx.Err() == nil; {
+		system.mutex.Lock()
+		if at, ok := system.clock.Next(); !ok || at > end {
+			system.clock.RunUntil(end)
+			running = false
+		} else {
+			system.clock.Step()
+		}
+		system.mutex.Unlock()
+	}
+	if ctx.Err() != nil {
+		system.log(ComponentSystem).Info("simulation interrupted", "at", system.clock.Now())
 	}
 
-	system := NewSystem()
-	if err := json.Unmarshal(data[:n], system); err != nil {
-		return nil, err
+	system.mutex.Lock()
+	system.stopped = true
+	system.mutex.Unlock()
+	system.log(ComponentSystem).Info("waiting for fractal rings to finish", "at", system.clock.Now())
+	for running := true; running; {
+		system.mutex.Lock()
+		running = system.clock.Step()
+		system.mutex.Unlock()
 	}
-	return system, nil
+	return system.errors, ctx.Err()
 }
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func testScenario() Scenario {
+	scenario := DefaultScenario()
+	scenario.Traders, scenario.Randoms, scenario.Bads = 30, 3, 2
+	scenario.Time, scenario.Seed = 10, 7
+	scenario.Params.Scheme = tools.SchemeEd25519
+	scenario.Params.VerificationMin, scenario.Params.VerificationMax = 5, 5
+	scenario.Params.FractalMin, scenario.Params.FractalMax = 10, 20
+	scenario.Faults = Faults{Latency: 20, Jitter: 10, Drop: 0.01, Reorder: 0.1}
+	return scenario
+}
+
+// runTest runs scenario with a journal and reads the live stats throughout,
+// the way the HTTP monitor does.
+func runTest(t *testing.T, ctx context.Context, scenario Scenario) (*System, []byte, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := OpenJournal(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	system := scenario.System(slog.New(slog.NewTextHandler(io.Discard, nil)), journal)
+	if err := scenario.Init(system); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for stats := system.LiveStats(); !stats.Stopped; stats = system.LiveStats() {
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	_, err = system.Start(ctx, scenario.RunTime())
+	<-done
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, readErr := os.ReadFile(path)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	return system, data, err
+}
+
+func TestStart(t *testing.T) {
+	system, first, err := runTest(t, context.Background(), testScenario())
+	if err != nil {
+		t.Fatal(err)
+	} else if len(system.Fractals) == 0 {
+		t.Error("no fractal ring was accepted")
+	} else if system.BadAcceptCount > 0 {
+		t.Errorf("%d invalid fractal rings were accepted", system.BadAcceptCount)
+	}
+
+	if _, second, _ := runTest(t, context.Background(), testScenario()); !bytes.Equal(first, second) {
+		t.Error("two runs with the same seed wrote different journals")
+	}
+}
+
+func TestStartCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	system, _, err := runTest(t, ctx, testScenario())
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	} else if !system.stopped {
+		t.Error("the system was not stopped")
+	}
+}
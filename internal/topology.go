@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+const (
+	TopologyComplete   = "complete"
+	TopologyRegular    = "regular"
+	TopologySmallWorld = "small-world"
+	TopologyScaleFree  = "scale-free"
+)
+
+// Topology is the overlay the traders know each other through. Outside the
+// complete graph a trader only stores its neighbors, spreads coins to them
+// and draws its verification teams from them. Degree is the number of
+// neighbors of every trader in a regular graph, the lattice degree of a
+// small-world graph, and twice the links every trader adds to a scale-free
+// graph. Rewire is the small-world rewiring probability.
+type Topology struct {
+	Kind   string  `json:"kind,omitempty"`
+	Degree int     `json:"degree,omitempty"`
+	Rewire float64 `json:"rewire,omitempty"`
+}
+
+func Topologies() []string {
+	return []string{TopologyComplete, TopologyRegular, TopologySmallWorld, TopologyScaleFree}
+}
+
+func (topology Topology) Complete() bool {
+	return topology.Kind == "" || topology.Kind == TopologyComplete
+}
+
+func (topology Topology) Validate(traders int) error {
+	if !slices.Contains(Topologies(), topology.Kind) && topology.Kind != "" {
+		return errors.New("unknown topology")
+	} else if topology.Complete() {
+		return nil
+	} else if topology.Rewire < 0 || topology.Rewire > 1 {
+		return errors.New("rewire probability must be between 0 and 1")
+	} else if topology.Degree < 2 || topology.Degree >= traders {
+		return errors.New("topology degree must be at least 2 and less than the number of traders")
+	} else if topology.Kind == TopologyRegular && topology.Degree*traders%2 != 0 {
+		return errors.New("regular topology needs an even degree or an even number of traders")
+	}
+	return nil
+}
+
+// MinDegree is the fewest neighbors a trader can end up with. A small-world
+// trader keeps only the lattice edges it rewires itself, and a scale-free one
+// only the links it adds.
+func (topology Topology) MinDegree() int {
+	switch topology.Kind {
+	case TopologyRegular:
+		return topology.Degree
+	case TopologySmallWorld:
+		if topology.Rewire == 0 {
+			return topology.Degree / 2 * 2
+		}
+		return topology.Degree / 2
+	case TopologyScaleFree:
+		return max(topology.Degree/2, 1)
+	}
+	return 0
+}
+
+func (system *System) SetTopology(topology Topology) {
+	system.topology = topology
+}
+
+// Build draws the overlay over ids and returns the sorted neighbors of every
+// node, or nil for the complete graph.
+func (topology Topology) Build(random *tools.Random, ids []string) map[string][]string {
+	var edges map[[2]int]bool
+	switch topology.Kind {
+	case TopologyRegular:
+		edges = regularGraph(random, len(ids), topology.Degree)
+	case TopologySmallWorld:
+		edges = smallWorldGraph(random, len(ids), topology.Degree, topology.Rewire)
+	case TopologyScaleFree:
+		edges = scaleFreeGraph(random, len(ids), max(topology.Degree/2, 1))
+	default:
+		return nil
+	}
+
+	neighbors := make(map[string][]string, len(ids))
+	for _, id := range ids {
+		neighbors[id] = []string{}
+	}
+	for edge := range edges {
+		a, b := ids[edge[0]], ids[edge[1]]
+		neighbors[a] = append(neighbors[a], b)
+		neighbors[b] = append(neighbors[b], a)
+	}
+	for _, list := range neighbors {
+		slices.Sort(list)
+	}
+	return neighbors
+}
+
+func edge(a, b int) [2]int {
+	return [2]int{min(a, b), max(a, b)}
+}
+
+// regularGraph pairs k stubs per node at random, starting over whenever the
+// remaining stubs can only form loops or repeated edges.
+func regularGraph(random *tools.Random, n, k int) map[[2]int]bool {
+	for {
+		edges := make(map[[2]int]bool, n*k/2)
+		stubs := make([]int, 0, n*k)
+		for node := range n {
+			for range k {
+				stubs = append(stubs, node)
+			}
+		}
+		for failures := 0; len(stubs) > 0 && failures < 100*n*k; {
+			i, j := random.IntN(len(stubs)), random.IntN(len(stubs))
+			a, b := stubs[i], stubs[j]
+			if i == j || a == b || edges[edge(a, b)] {
+				failures++
+				continue
+			}
+			edges[edge(a, b)] = true
+			i, j = max(i, j), min(i, j)
+			stubs[i] = stubs[len(stubs)-1]
+			stubs[j] = stubs[len(stubs)-2]
+			stubs = stubs[:len(stubs)-2]
+		}
+		if len(stubs) == 0 {
+			return edges
+		}
+	}
+}
+
+// smallWorldGraph is the Watts-Strogatz ring lattice of degree k with every
+// edge moved to a random endpoint with probability beta.
+func smallWorldGraph(random *tools.Random, n, k int, beta float64) map[[2]int]bool {
+	edges := make(map[[2]int]bool, n*k/2)
+	for node := range n {
+		for step := 1; step <= k/2; step++ {
+			edges[edge(node, (node+step)%n)] = true
+		}
+	}
+	for step := 1; step <= k/2; step++ {
+		for node := range n {
+			lattice := edge(node, (node+step)%n)
+			if !edges[lattice] || random.Float64() >= beta {
+				continue
+			}
+			target := random.IntN(n)
+			if target == node || edges[edge(node, target)] {
+				continue
+			}
+			delete(edges, lattice)
+			edges[edge(node, target)] = true
+		}
+	}
+	return edges
+}
+
+// scaleFreeGraph is the Barabasi-Albert graph: every node after the first
+// m+1 links to m distinct earlier nodes picked in proportion to their degree.
+func scaleFreeGraph(random *tools.Random, n, m int) map[[2]int]bool {
+	edges := make(map[[2]int]bool, n*m)
+	var ends []int
+	for a := 0; a <= m && a < n; a++ {
+		for b := 0; b < a; b++ {
+			edges[edge(a, b)] = true
+			ends = append(ends, a, b)
+		}
+	}
+	for node := m + 1; node < n; node++ {
+		targets := make([]int, 0, m)
+		for len(targets) < m {
+			if target := ends[random.IntN(len(ends))]; !slices.Contains(targets, target) {
+				targets = append(targets, target)
+			}
+		}
+		for _, target := range targets {
+			edges[edge(node, target)] = true
+			ends = append(ends, node, target)
+		}
+	}
+	return edges
+}
+
+// connect draws the overlay and hands every trader its neighbors.
+func (system *System) connect() {
+	system.Overlay = system.topology.Build(system.random, system.traderIDs())
+	system.applyOverlay()
+}
+
+func (system *System) applyOverlay() {
+	if system.Overlay == nil {
+		return
+	}
+	for traderID, trader := range system.Traders {
+		if trader.Data != nil {
+			trader.Data.Neighbors, trader.Data.Overlay = system.Overlay[traderID], system.Overlay
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+func TestMinDegree(t *testing.T) {
+	ids := make([]string, 60)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+	for _, topology := range []Topology{
+		{Kind: TopologyRegular, Degree: 21},
+		{Kind: TopologySmallWorld, Degree: 21},
+		{Kind: TopologySmallWorld, Degree: 21, Rewire: 0.5},
+		{Kind: TopologyScaleFree, Degree: 21},
+	} {
+		for seed := uint64(1); seed <= 5; seed++ {
+			for id, neighbors := range topology.Build(tools.NewRandom(seed), ids) {
+				if len(neighbors) < topology.MinDegree() {
+					t.Errorf("%+v seed %d: trader %s has %d neighbors, below %d", topology, seed, id, len(neighbors), topology.MinDegree())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/Arka-Lab/LoR/pkg"
+)
+
+// WireVersion is the version of the framing and message encoding node
+// daemons speak. A frame is a big-endian uint32 length followed by a JSON
+// envelope of that many bytes.
+const (
+	WireVersion  = 1
+	MaxFrameSize = 16 << 20
+)
+
+type envelope struct {
+	Version int             `json:"version"`
+	Type    MessageType     `json:"type"`
+	From    string          `json:"from"`
+	Body    json.RawMessage `json:"body"`
+}
+
+var wireBodies = map[MessageType]func(json.RawMessage) (Body, error){
+	MessageHello:        decodeBody[Hello],
+	MessageCoin:         decodeBody[CoinAnnouncement],
+	MessageProposal:     decodeBody[FractalProposal],
+	MessageVerification: decodeBody[VerificationVote],
+	MessageAccepted:     decodeBody[AcceptedFractal],
+}
+
+func decodeBody[T Body](data json.RawMessage) (Body, error) {
+	var body T
+	err := json.Unmarshal(data, &body)
+	return body, err
+}
+
+func WriteFrame(w io.Writer, message Message) error {
+	body, err := json.Marshal(message.Body)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(envelope{Version: WireVersion, Type: message.Body.MessageType(), From: message.From, Body: body})
+	if err != nil {
+		return err
+	} else if len(data) > MaxFrameSize {
+		return errors.New("frame too large")
+	}
+
+	frame := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(data)), uint32(len(data)))
+	_, err = w.Write(append(frame, data...))
+	return err
+}
+
+func ReadFrame(r io.Reader) (Message, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return Message{}, err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxFrameSize {
+		return Message{}, errors.New("frame too large")
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return Message{}, err
+	}
+
+	var e envelope
+	if err := json.Unmarshal(data, &e); err != nil {
+		return Message{}, err
+	} else if e.Version != WireVersion {
+		return Message{}, fmt.Errorf("unsupported wire version %d", e.Version)
+	}
+	decode, ok := wireBodies[e.Type]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown message type %s", e.Type)
+	}
+	body, err := decode(e.Body)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{From: e.From, Body: body}, nil
+}
+
+// wireFractal carries the parts of a fractal ring that its JSON form leaves
+// out but verifiers need to repeat its draws: the solo rings it was drawn
+// from and the unused coins of every cooperation ring.
+type wireFractal struct {
+	pkg.FractalRing
+	SoloRings   []string     `json:"solo_rings"`
+	UnusedCoins [][][]string `json:"unused_coins"`
+}
+
+func toWire(fractal pkg.FractalRing) wireFractal {
+	wire := wireFractal{FractalRing: fractal, SoloRings: fractal.SoloRings}
+	for _, cooperation := range fractal.CooperationRings {
+		wire.UnusedCoins = append(wire.UnusedCoins, cooperation.UnusedCoins)
+	}
+	return wire
+}
+
+func (wire wireFractal) fractal() pkg.FractalRing {
+	fractal := wire.FractalRing
+	fractal.SoloRings = wire.SoloRings
+	fractal.CooperationRings = slices.Clone(fractal.CooperationRings)
+	for i := range min(len(wire.UnusedCoins), len(fractal.CooperationRings)) {
+		fractal.CooperationRings[i].UnusedCoins = wire.UnusedCoins[i]
+	}
+	return fractal
+}
+
+func (proposal FractalProposal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Fractal wireFractal `json:"fractal"`
+	}{toWire(proposal.Fractal)})
+}
+
+func (proposal *FractalProposal) UnmarshalJSON(data []byte) error {
+	var wire struct {
+		Fractal wireFractal `json:"fractal"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	proposal.Fractal = wire.Fractal.fractal()
+	return nil
+}
+
+type wireAccepted struct {
+	Fractal  wireFractal `json:"fractal"`
+	Beacon   string      `json:"beacon,omitempty"`
+	Sequence int         `json:"sequence,omitempty"`
+}
+
+func (accepted AcceptedFractal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(wireAccepted{toWire(accepted.Fractal), accepted.Beacon, accepted.Sequence})
+}
+
+func (accepted *AcceptedFractal) UnmarshalJSON(data []byte) error {
+	var wire wireAccepted
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return err
+	}
+	accepted.Fractal, accepted.Beacon, accepted.Sequence = wire.Fractal.fractal(), wire.Beacon, wire.Sequence
+	return nil
+}
@@ -0,0 +1,176 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/exp/maps"
+)
+
+const (
+	BehaviorNormal = "normal"
+	BehaviorRandom = "random"
+	BehaviorBad    = "bad"
+)
+
+// Behavior decides how a trader acts wherever the protocol leaves it a choice.
+// ProposeRing and ChooseTeam report whether their choice follows the protocol,
+// ValidateSubmission receives the error found while checking a submitted fractal
+// ring and returns the error the trader reports, and Vote is asked once when a
+// fractal ring is submitted (ring and round are -1) and then once per ring and round.
+type Behavior interface {
+	Name() string
+	ProposeRing(t *Trader, soloRings []string) ([]string, bool)
+	ChooseTeam(t *Trader, ring []string) ([]string, bool)
+	ValidateSubmission(t *Trader, fractal *FractalRing, err error) error
+	Vote(t *Trader, fractal *FractalRing, ring, round int) bool
+}
+
+// Behavior names may carry a group after a slash, such as coalition/a or
+// sybil/2, which the factory uses to tell apart the coalitions or principals
+// that traders of the same behavior belong to.
+var behaviors = map[string]func(society *Society, group string) Behavior{
+	BehaviorNormal: func(*Society, string) Behavior { return Honest{} },
+	BehaviorRandom: func(*Society, string) Behavior { return Dishonest{name: BehaviorRandom} },
+	BehaviorBad:    func(*Society, string) Behavior { return Dishonest{name: BehaviorBad, always: true} },
+	BehaviorCoalition: func(society *Society, group string) Behavior {
+		coalition := society.Coalition(GroupName(BehaviorCoalition, group))
+		return Colluder{Dishonest: Dishonest{name: coalition.Name}, coalition: coalition}
+	},
+	BehaviorGrinder: func(society *Society, group string) Behavior {
+		coalition := society.Coalition(GroupName(BehaviorCoalition, group))
+		return Grinder{Colluder{Dishonest: Dishonest{name: GroupName(BehaviorGrinder, group), always: true}, coalition: coalition}}
+	},
+	BehaviorSybil: func(society *Society, group string) Behavior {
+		principal := society.Coalition(GroupName(BehaviorSybil, group))
+		return Sybil{Colluder{Dishonest: Dishonest{name: principal.Name, always: true}, coalition: principal}}
+	},
+}
+
+var overlookedErrors = []error{ErrInvalidSelection, ErrInvalidTeam, ErrInvalidCooperationRing}
+
+func RegisterBehavior(name string, factory func(society *Society, group string) Behavior) {
+	behaviors[name] = factory
+}
+
+func NewBehavior(name string, society *Society) (Behavior, error) {
+	base, group := SplitBehavior(name)
+	factory, ok := behaviors[base]
+	if !ok {
+		return nil, fmt.Errorf("unknown behavior %s", name)
+	}
+	return factory(society, group), nil
+}
+
+func GroupName(behavior, group string) string {
+	if group == "" {
+		return behavior
+	}
+	return behavior + "/" + group
+}
+
+func SplitBehavior(name string) (string, string) {
+	base, group, _ := strings.Cut(name, "/")
+	return base, group
+}
+
+func BehaviorNames() []string {
+	names := maps.Keys(behaviors)
+	slices.Sort(names)
+	return names
+}
+
+type Honest struct{}
+
+func (Honest) Name() string {
+	return BehaviorNormal
+}
+
+func (Honest) ProposeRing(t *Trader, soloRings []string) ([]string, bool) {
+	return selectFractalRing(t.Data.Random, t.Data.Params, soloRings, ""), true
+}
+
+func (Honest) ChooseTeam(t *Trader, ring []string) ([]string, bool) {
+	traders := t.candidates()
+	return selectVerificationTeam(t.Data.Random, t.Data.Params, traders, ring, t.teamLeader(traders)), true
+}
+
+func (Honest) ValidateSubmission(t *Trader, fractal *FractalRing, err error) error {
+	return err
+}
+
+func (Honest) Vote(t *Trader, fractal *FractalRing, ring, round int) bool {
+	return true
+}
+
+// Dishonest breaks the protocol at every choice, either always or with the
+// probability given by Params.BadBehavior.
+type Dishonest struct {
+	name   string
+	always bool
+}
+
+func (b Dishonest) misbehave(t *Trader) bool {
+	return b.always || t.Data.Random.Float64() < t.Data.Params.BadBehavior
+}
+
+func (b Dishonest) Name() string {
+	return b.name
+}
+
+func (b Dishonest) ProposeRing(t *Trader, soloRings []string) ([]string, bool) {
+	if b.misbehave(t) {
+		return selectRandomFractal(t.Data.Random, t.Data.Params, soloRings), false
+	}
+	return Honest{}.ProposeRing(t, soloRings)
+}
+
+func (b Dishonest) ChooseTeam(t *Trader, ring []string) ([]string, bool) {
+	if b.misbehave(t) {
+		return selectRandomVerification(t.Data.Random, t.Data.Params, t.candidates()), false
+	}
+	return Honest{}.ChooseTeam(t, ring)
+}
+
+func (b Dishonest) ValidateSubmission(t *Trader, fractal *FractalRing, err error) error {
+	if slices.ContainsFunc(overlookedErrors, func(target error) bool { return errors.Is(err, target) }) && b.misbehave(t) {
+		return nil
+	}
+	return err
+}
+
+func (b Dishonest) Vote(t *Trader, fractal *FractalRing, ring, round int) bool {
+	return !b.misbehave(t)
+}
+
+func (t *Trader) sortedTraderIDs() []string {
+	traders := maps.Keys(t.Data.Traders)
+	slices.Sort(traders)
+	return traders
+}
+
+// candidates returns the traders a verification team may be drawn from: the
+// trader and its neighbors, or every trader it knows without a topology.
+func (t *Trader) candidates() []string {
+	if neighborhood := t.neighborhood(); neighborhood != nil {
+		return neighborhood
+	}
+	return t.sortedTraderIDs()
+}
+
+func (t *Trader) neighborhood() []string {
+	return neighborhood(t.ID, t.Data.Neighbors)
+}
+
+// neighborhood is a trader and its neighbors, sorted, or nil for a trader
+// outside any topology.
+func neighborhood(traderID string, neighbors []string) []string {
+	if neighbors == nil {
+		return nil
+	}
+	result := append(slices.Clone(neighbors), traderID)
+	slices.Sort(result)
+	return slices.Compact(result)
+}
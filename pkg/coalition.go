@@ -0,0 +1,90 @@
+package pkg
+
+const BehaviorCoalition = "coalition"
+
+// Society holds what the behaviors of one simulation share, such as the member
+// sets of coalitions.
+type Society struct {
+	coalitions map[string]*Coalition
+}
+
+type Coalition struct {
+	Name    string
+	members map[string]bool
+}
+
+// Member is implemented by behaviors that have to register their trader with
+// the society once its ID is known.
+type Member interface {
+	Join(t *Trader)
+}
+
+// Colluder belongs to a coalition whose members approve each other's fractal
+// rings, reject everyone else's and pack their verification teams with members.
+type Colluder struct {
+	Dishonest
+	coalition *Coalition
+}
+
+func NewSociety() *Society {
+	return &Society{coalitions: make(map[string]*Coalition)}
+}
+
+func (society *Society) Coalition(name string) *Coalition {
+	coalition, ok := society.coalitions[name]
+	if !ok {
+		coalition = &Coalition{Name: name, members: make(map[string]bool)}
+		society.coalitions[name] = coalition
+	}
+	return coalition
+}
+
+func (coalition *Coalition) Add(traderID string) {
+	coalition.members[traderID] = true
+}
+
+func (coalition *Coalition) Has(traderID string) bool {
+	return coalition.members[traderID]
+}
+
+func (coalition *Coalition) Size() int {
+	return len(coalition.members)
+}
+
+func (b Colluder) Join(t *Trader) {
+	b.coalition.Add(t.ID)
+}
+
+func (b Colluder) ChooseTeam(t *Trader, ring []string) ([]string, bool) {
+	k := teamSize(t.Data.Params, ring)
+	traders := t.candidates()
+	if len(traders) < k {
+		return nil, false
+	}
+
+	members, others := []string{}, []string{}
+	for _, traderID := range traders {
+		if b.coalition.Has(traderID) {
+			members = append(members, traderID)
+		} else {
+			others = append(others, traderID)
+		}
+	}
+	for _, group := range [][]string{members, others} {
+		t.Data.Random.Shuffle(len(group), func(i, j int) {
+			group[i], group[j] = group[j], group[i]
+		})
+	}
+	return append(members, others...)[:k], false
+}
+
+func (b Colluder) ValidateSubmission(t *Trader, fractal *FractalRing, err error) error {
+	if b.coalition.Has(fractal.Submitter) {
+		return nil
+	}
+	return err
+}
+
+func (b Colluder) Vote(t *Trader, fractal *FractalRing, ring, round int) bool {
+	return b.coalition.Has(fractal.Submitter)
+}
@@ -1,7 +1,6 @@
 package pkg
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/Arka-Lab/LoR/tools"
@@ -24,6 +23,7 @@ type CoinTable struct {
 	Next   string  `json:"next"`
 	Prev   string  `json:"prev"`
 	Owner  string  `json:"owner"`
+	Nonce  uint64  `json:"nonce,omitempty"`
 
 	CooperationID string
 }
@@ -32,7 +32,13 @@ func (t *Trader) CreateCoin(amount float64, coinType uint) *CoinTable {
 	if t.Account < amount {
 		return nil
 	}
-	id, err := tools.SignWithPrivateKeyStr(t.ID+"-"+fmt.Sprint(coinType), t.Data.PrivateKey)
+	// Only RSA-PSS signatures are salted, so other schemes need a nonce to give
+	// the coins of one type distinct IDs.
+	var nonce uint64
+	if t.Data.Signer.Scheme() != tools.SchemeRSAPSS {
+		nonce = t.Data.Random.Uint64()
+	}
+	id, err := tools.SignStr(t.Data.Signer, coinMessage(t.ID, coinType, nonce), t.Data.Random)
 	if err != nil {
 		return nil
 	}
@@ -43,24 +49,34 @@ func (t *Trader) CreateCoin(amount float64, coinType uint) *CoinTable {
 		Status: Run,
 		Type:   coinType,
 		Owner:  t.ID,
+		Nonce:  nonce,
+	}
+}
+
+func coinMessage(owner string, coinType uint, nonce uint64) string {
+	if nonce == 0 {
+		return owner + "-" + fmt.Sprint(coinType)
 	}
+	return owner + "-" + fmt.Sprint(coinType) + "-" + fmt.Sprint(nonce)
 }
 
 func (t *Trader) SaveCoin(coin CoinTable) error {
 	if coin.Status != Run {
-		return errors.New("invalid coin status")
+		return coinError(ErrInvalidCoinStatus, coin.ID)
 	} else if coin.Type >= t.Data.CoinTypeCount {
-		return errors.New("invalid coin type")
+		return coinError(ErrInvalidCoinType, coin.ID)
 	} else if trader, ok := t.Data.Traders[coin.Owner]; !ok {
-		return errors.New("trader not found")
+		return coinError(ErrTraderNotFound, coin.ID)
 	} else if trader.Account < coin.Amount {
-		return errors.New("insufficient account")
-	} else if err := tools.VerifyWithPublicKeyStr(coin.Owner+"-"+fmt.Sprint(coin.Type), coin.ID, trader.PublicKey); err != nil {
-		return errors.New("invalid coin id")
+		return coinError(ErrInsufficientAccount, coin.ID)
+	} else if trader.PublicKey.Verifier == nil {
+		return coinError(ErrInvalidCoinID, coin.ID)
+	} else if err := tools.VerifyStr(trader.PublicKey, coinMessage(coin.Owner, coin.Type, coin.Nonce), coin.ID); err != nil {
+		return coinError(ErrInvalidCoinID, coin.ID)
 	} else if coin.Next != "" || coin.Prev != "" {
-		return errors.New("coin is already in a ring")
+		return coinError(ErrCoinInRing, coin.ID)
 	} else if _, ok := t.Data.Coins[coin.ID]; ok {
-		return errors.New("coin already exist")
+		return coinError(ErrCoinExists, coin.ID)
 	}
 
 	trader := t.Data.Traders[coin.Owner]
@@ -71,7 +87,7 @@ func (t *Trader) SaveCoin(coin CoinTable) error {
 
 func (t *Trader) UpdateCoin(coin CoinTable) error {
 	if _, ok := t.Data.Coins[coin.ID]; !ok {
-		return errors.New("coin not found")
+		return coinError(ErrCoinNotFound, coin.ID)
 	}
 
 	c := t.Data.Coins[coin.ID]
@@ -1,17 +1,10 @@
 package pkg
 
 import (
-	"errors"
 	"reflect"
 	"slices"
 
 	"github.com/Arka-Lab/LoR/tools"
-	"golang.org/x/exp/rand"
-)
-
-const (
-	RoundsCount = 10
-	RoundLength = 1000
 )
 
 type CooperationTable struct {
@@ -40,11 +33,12 @@ func (t *Trader) checkForCooperationRing() *CooperationTable {
 		if len(coins) == 0 {
 			return nil
 		}
+		slices.Sort(coins)
 	}
 
 	isValid := true
 	var selectedCoins []string
-	selectedCoins = selectCooperationRing(unusedCoins, "")
+	selectedCoins = selectCooperationRing(t.Data.Random, unusedCoins, "")
 
 	cooperationID := tools.SHA256Str(selectedCoins)
 	for i, coinID := range selectedCoins {
@@ -75,52 +69,52 @@ func (t *Trader) calculateWeight(ring []string) (weight float64) {
 
 func (t *Trader) validateCooperationRing(cooperation CooperationTable) error {
 	if cooperation.ID != tools.SHA256Str(cooperation.CoinIDs) {
-		return errors.New("invalid cooperation ring id")
+		return cooperationError(ErrInvalidCooperationID, cooperation.ID)
 	} else if cooperation.Weight != t.calculateWeight(cooperation.CoinIDs) {
-		return errors.New("invalid cooperation ring weight")
+		return cooperationError(ErrInvalidWeight, cooperation.ID)
 	} else if cooperation.Investor != cooperation.CoinIDs[0] {
-		return errors.New("invalid cooperation ring investor")
+		return cooperationError(ErrInvalidInvestor, cooperation.ID)
 	}
 
 	for i, coinID := range cooperation.CoinIDs {
 		if coin, ok := t.Data.Coins[coinID]; !ok {
-			return errors.New("coin not found")
+			return coinError(ErrCoinNotFound, coinID)
 		} else if coin.Status != Run {
-			return errors.New("invalid coin status")
+			return coinError(ErrInvalidCoinStatus, coinID)
 		} else if coin.Type != uint(i) {
-			return errors.New("invalid coin type")
+			return coinError(ErrInvalidCoinType, coinID)
 		}
 	}
 
-	expectedRing := selectCooperationRing(cooperation.UnusedCoins, cooperation.Investor)
+	if len(cooperation.UnusedCoins) != len(cooperation.CoinIDs) || slices.ContainsFunc(cooperation.UnusedCoins, func(coins []string) bool { return len(coins) == 0 }) {
+		return cooperationError(ErrInvalidCooperationRing, cooperation.ID)
+	}
+	expectedRing := selectCooperationRing(t.Data.Random, cooperation.UnusedCoins, cooperation.Investor)
 	if !reflect.DeepEqual(expectedRing, cooperation.CoinIDs) {
-		return errors.New("invalid cooperation ring coins")
+		return cooperationError(ErrInvalidCooperationRing, cooperation.ID)
 	}
 	return nil
 }
 
-func selectRandomCooperation(unusedCoins [][]string) []string {
+func selectRandomCooperation(random *tools.Random, unusedCoins [][]string) []string {
 	selectedRing := make([]string, len(unusedCoins))
 	for i := 0; i < len(unusedCoins); i++ {
-		selectedRing[i] = unusedCoins[i][rand.Intn(len(unusedCoins[i]))]
+		selectedRing[i] = unusedCoins[i][random.IntN(len(unusedCoins[i]))]
 	}
 	return selectedRing
 }
 
-func selectCooperationRing(unusedCoins [][]string, investor string) []string {
-	rnd := make([]int, 0)
+func selectCooperationRing(random *tools.Random, unusedCoins [][]string, investor string) []string {
 	selectedRing := make([]string, len(unusedCoins))
 	if investor == "" {
-		selectedRing[0] = unusedCoins[0][rand.Intn(len(unusedCoins[0]))]
+		selectedRing[0] = unusedCoins[0][random.IntN(len(unusedCoins[0]))]
 	} else {
 		selectedRing[0] = investor
 	}
+	drbg := tools.NewDRBG(selectedRing)
 	for i := 1; i < len(unusedCoins); i++ {
-		if len(rnd) == 0 {
-			rnd = tools.SHA256Arr(selectedRing)
-		}
 		slices.Sort(unusedCoins[i])
-		rnd, selectedRing[i] = rnd[1:], unusedCoins[i][rnd[0]%len(unusedCoins[i])]
+		selectedRing[i] = unusedCoins[i][drbg.IntN(len(unusedCoins[i]))]
 	}
 	return selectedRing
 }
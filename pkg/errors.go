@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBadBehavior         = errors.New("bad behavior")
+	ErrTraderNotFound      = errors.New("trader not found")
+	ErrTraderExists        = errors.New("trader already exist")
+	ErrInvalidTraderID     = errors.New("invalid trader ID")
+	ErrInsufficientAccount = errors.New("insufficient account")
+
+	ErrCoinNotFound      = errors.New("coin not found")
+	ErrCoinExists        = errors.New("coin already exist")
+	ErrCoinInRing        = errors.New("coin is already in a ring")
+	ErrCoinNotRunning    = errors.New("coin is not running")
+	ErrInvalidCoinID     = errors.New("invalid coin id")
+	ErrInvalidCoinStatus = errors.New("invalid coin status")
+	ErrInvalidCoinType   = errors.New("invalid coin type")
+
+	ErrCooperationNotFound    = errors.New("cooperation ring not found")
+	ErrInvalidCooperationID   = errors.New("invalid cooperation ring id")
+	ErrInvalidWeight          = errors.New("invalid cooperation ring weight")
+	ErrInvalidInvestor        = errors.New("invalid cooperation ring investor")
+	ErrInvalidCooperationRing = errors.New("invalid cooperation ring coins")
+
+	ErrInvalidFractalID  = errors.New("invalid fractal ring id")
+	ErrInvalidSelection  = errors.New("invalid selected cooperation ring")
+	ErrInvalidTeam       = errors.New("invalid verification team")
+	ErrInvalidCandidates = errors.New("invalid verification candidates")
+	ErrNotNeighbor       = errors.New("submitter is not a neighbor")
+)
+
+// ProtocolError is one of the errors above along with the coin, cooperation
+// ring, fractal ring or trader it is about.
+type ProtocolError struct {
+	Err     error
+	Subject string
+	ID      string
+}
+
+func (e *ProtocolError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Subject, e.ID, e.Err)
+}
+
+func (e *ProtocolError) Unwrap() error {
+	return e.Err
+}
+
+func coinError(err error, coinID string) error {
+	return &ProtocolError{Err: err, Subject: "coin", ID: coinID}
+}
+
+func cooperationError(err error, cooperationID string) error {
+	return &ProtocolError{Err: err, Subject: "cooperation ring", ID: cooperationID}
+}
+
+func fractalError(err error, fractalID string) error {
+	return &ProtocolError{Err: err, Subject: "fractal ring", ID: fractalID}
+}
+
+func traderError(err error, traderID string) error {
+	return &ProtocolError{Err: err, Subject: "trader", ID: traderID}
+}
+
+// Reason returns the message of the protocol error err wraps, without the ID
+// it is about, so that errors can be counted by kind.
+func Reason(err error) string {
+	var protocolErr *ProtocolError
+	if errors.As(err, &protocolErr) {
+		return protocolErr.Err.Error()
+	}
+	return err.Error()
+}
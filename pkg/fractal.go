@@ -1,25 +1,18 @@
 package pkg
 
 import (
-	"errors"
 	"reflect"
 	"slices"
 
 	"github.com/Arka-Lab/LoR/tools"
-	"golang.org/x/exp/maps"
-	"golang.org/x/exp/rand"
-)
-
-const (
-	FractalMin   = 50
-	FractalMax   = 200
-	FractalPrize = 5
 )
 
 type FractalRing struct {
 	ID               string             `json:"id"`
 	CooperationRings []CooperationTable `json:"cooperation_rings"`
 	VerificationTeam []string           `json:"verification_team"`
+	Submitter        string             `json:"submitter,omitempty"`
+	Candidates       []string           `json:"candidates,omitempty"`
 
 	SoloRings []string `json:"-"`
 	IsValid   bool
@@ -48,6 +41,8 @@ func (t *Trader) checkForFractalRing() *FractalRing {
 		CooperationRings: selectedCooperations,
 		SoloRings:        soloRings,
 		VerificationTeam: team,
+		Submitter:        t.ID,
+		Candidates:       t.neighborhood(),
 	}
 }
 
@@ -58,24 +53,24 @@ func (t *Trader) getSoloRings() []string {
 			soloRings = append(soloRings, cooperation.ID)
 		}
 	}
+	slices.Sort(soloRings)
 	return soloRings
 }
 
 func (t *Trader) getSelectedRing(soloRings []string, isValid *bool) []string {
-	if t.Data.TraderType == BadVote || (t.Data.TraderType == RandomVote && rand.Float64() < BadBehavior) {
+	ring, honest := t.Data.Behavior.ProposeRing(t, soloRings)
+	if !honest {
 		*isValid = false
-		return selectRandomFractal(soloRings)
 	}
-	return selectFractalRing(soloRings, "")
+	return ring
 }
 
 func (t *Trader) getVerificationTeam(selectedRing []string, isValid *bool) []string {
-	traders := maps.Keys(t.Data.Traders)
-	if t.Data.TraderType == BadVote || (t.Data.TraderType == RandomVote && rand.Float64() < BadBehavior) {
+	team, honest := t.Data.Behavior.ChooseTeam(t, selectedRing)
+	if !honest {
 		*isValid = false
-		return selectRandomVerification(traders)
 	}
-	return selectVerificationTeam(traders, selectedRing, "")
+	return team
 }
 
 func (t *Trader) updateCooperations(selectedRing []string, fractalID string, isValid *bool) []CooperationTable {
@@ -102,40 +97,62 @@ func (t *Trader) validateFractalRing(fractal *FractalRing) error {
 		}
 		selectedRings = append(selectedRings, cooperation.ID)
 	}
-	traders := maps.Keys(t.Data.Traders)
+	traders := t.sortedTraderIDs()
+	if fractal.Candidates != nil || t.Data.Overlay != nil {
+		if err := t.validateCandidates(fractal); err != nil {
+			return err
+		} else if fractal.Candidates != nil {
+			traders = fractal.Candidates
+		}
+	}
+	leader := beaconLeader(t.Data.Params, t.Data.Beacon, fractal.Submitter, traders)
 
 	if fractal.ID != tools.SHA256Str(selectedRings) {
-		return errors.New("invalid fractal ring id")
-	} else if !reflect.DeepEqual(selectedRings, selectFractalRing(fractal.SoloRings, selectedRings[0])) {
-		return errors.New("invalid selected cooperation ring")
-	} else if !reflect.DeepEqual(fractal.VerificationTeam, selectVerificationTeam(traders, selectedRings, fractal.VerificationTeam[0])) {
-		return errors.New("invalid verification team")
+		return fractalError(ErrInvalidFractalID, fractal.ID)
+	} else if !reflect.DeepEqual(selectedRings, selectFractalRing(t.Data.Random, t.Data.Params, fractal.SoloRings, selectedRings[0])) {
+		return fractalError(ErrInvalidSelection, fractal.ID)
+	} else if leader != "" && fractal.VerificationTeam[0] != leader {
+		return fractalError(ErrInvalidTeam, fractal.ID)
+	} else if !reflect.DeepEqual(fractal.VerificationTeam, selectVerificationTeam(t.Data.Random, t.Data.Params, traders, selectedRings, fractal.VerificationTeam[0])) {
+		return fractalError(ErrInvalidTeam, fractal.ID)
+	}
+	return nil
+}
+
+// validateCandidates checks the traders a submitter with a partial view drew
+// its team from against the submitter's neighborhood in the published
+// overlay, so a submitter cannot pick the traders its team comes from.
+func (t *Trader) validateCandidates(fractal *FractalRing) error {
+	if t.Data.Neighbors != nil && t.ID != fractal.Submitter && !slices.Contains(t.Data.Neighbors, fractal.Submitter) {
+		return fractalError(ErrNotNeighbor, fractal.ID)
+	} else if !slices.Equal(fractal.Candidates, neighborhood(fractal.Submitter, t.Data.Overlay[fractal.Submitter])) {
+		return fractalError(ErrInvalidCandidates, fractal.ID)
 	}
 	return nil
 }
 
-func selectRandomFractal(soloRings []string) (result []string) {
-	if len(soloRings) < FractalMin {
+func selectRandomFractal(random *tools.Random, params Params, soloRings []string) (result []string) {
+	if len(soloRings) < params.FractalMin {
 		return nil
 	}
 
-	k := FractalMin + tools.SHA256Int(soloRings)%(FractalMax-FractalMin+1)
+	k := fractalSize(params, soloRings)
 	if len(soloRings) < k {
 		return nil
 	}
 
-	for _, index := range tools.RandomIndexes(len(soloRings), k) {
+	for _, index := range tools.RandomIndexes(random, len(soloRings), k) {
 		result = append(result, soloRings[index])
 	}
 	return
 }
 
-func selectFractalRing(soloRings []string, firstRing string) (result []string) {
-	if len(soloRings) < FractalMin {
+func selectFractalRing(random *tools.Random, params Params, soloRings []string, firstRing string) (result []string) {
+	if len(soloRings) < params.FractalMin {
 		return nil
 	}
 
-	k := FractalMin + tools.SHA256Int(soloRings)%(FractalMax-FractalMin+1)
+	k := fractalSize(params, soloRings)
 	if len(soloRings) < k {
 		return nil
 	}
@@ -155,23 +172,17 @@ func selectFractalRing(soloRings []string, firstRing string) (result []string) {
 			}
 		}
 	} else {
-		index := rand.Intn(len(soloRings))
+		index := random.IntN(len(soloRings))
 		result[0] = copiedRings[index]
 
 		copiedRings[index] = copiedRings[0]
 		copiedRings = copiedRings[1:]
 	}
 
-	rnd := make([]int, 0)
-	for i := 1; i < k; i++ {
-		if len(rnd) == 0 {
-			rnd = tools.SHA256Arr(result)
-		}
-		index := rnd[0] % len(copiedRings)
-		result[i], rnd = copiedRings[index], rnd[1:]
-
-		copiedRings[index] = copiedRings[0]
-		copiedRings = copiedRings[1:]
-	}
+	tools.FillSample(result, copiedRings)
 	return
 }
+
+func fractalSize(params Params, soloRings []string) int {
+	return params.FractalMin + tools.NewDRBG(soloRings).IntN(params.FractalMax-params.FractalMin+1)
+}
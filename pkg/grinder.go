@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"strconv"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+const BehaviorGrinder = "grinder"
+
+// Grinder is a coalition member that submits invalid fractal rings with a
+// protocol-valid verification team, found by trying up to Params.GrindBudget
+// first members and keeping the team with the most coalition members.
+type Grinder struct {
+	Colluder
+}
+
+func (b Grinder) ChooseTeam(t *Trader, ring []string) ([]string, bool) {
+	traders := t.candidates()
+	if first := t.teamLeader(traders); first != "" {
+		return selectVerificationTeam(t.Data.Random, t.Data.Params, traders, ring, first), true
+	}
+	return grindTeam(t.Data.Random, t.Data.Params, traders, ring, b.coalition.Has), true
+}
+
+func grindTeam(random *tools.Random, params Params, traders, ring []string, isMember func(string) bool) (best []string) {
+	bestCount := -1
+	for _, index := range random.Perm(len(traders))[:min(max(params.GrindBudget, 1), len(traders))] {
+		team := selectVerificationTeam(random, params, traders, ring, traders[index])
+		count := 0
+		for _, traderID := range team {
+			if isMember(traderID) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = team, count
+		}
+	}
+	return
+}
+
+// teamLeader returns the first verification team member that Params.BeaconTeams
+// imposes on the trader, or an empty string if the submitter may pick it.
+func (t *Trader) teamLeader(traders []string) string {
+	return beaconLeader(t.Data.Params, t.Data.Beacon, t.ID, traders)
+}
+
+func beaconLeader(params Params, beacon, submitter string, traders []string) string {
+	if !params.BeaconTeams || len(traders) == 0 {
+		return ""
+	}
+	return traders[tools.NewDRBG([]string{beacon, submitter}).IntN(len(traders))]
+}
+
+// NextBeacon folds an accepted fractal ring into the beacon.
+func NextBeacon(beacon, fractalID string) string {
+	return tools.SHA256Str([]string{beacon, fractalID})
+}
+
+// GrindingOdds estimates by simulation the probability that a submitter with
+// the given budget gets a verification team in which the coalition holds at
+// least half of the seats, which is enough for the team to accept the ring,
+// along with the average number of seats the coalition holds.
+func GrindingOdds(random *tools.Random, params Params, traders, members int, trials int) (float64, float64) {
+	ids := make([]string, traders)
+	for i := range ids {
+		ids[i] = tools.SHA256Str(i)
+	}
+	isMember := make(map[string]bool, members)
+	for _, index := range random.Perm(traders)[:members] {
+		isMember[ids[index]] = true
+	}
+
+	captured, seats := 0, 0
+	for trial := 0; trial < trials; trial++ {
+		ring := []string{tools.SHA256Str("ring-" + strconv.Itoa(trial))}
+		var team []string
+		if params.BeaconTeams {
+			team = selectVerificationTeam(random, params, ids, ring, beaconLeader(params, tools.SHA256Str(trial), ids[0], ids))
+		} else {
+			team = grindTeam(random, params, ids, ring, func(traderID string) bool { return isMember[traderID] })
+		}
+
+		count := 0
+		for _, traderID := range team {
+			if isMember[traderID] {
+				count++
+			}
+		}
+		if team != nil && 2*count >= len(team) {
+			captured++
+		}
+		seats += count
+	}
+	return float64(captured) / float64(trials), float64(seats) / float64(trials)
+}
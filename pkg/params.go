@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+type Params struct {
+	FractalMin      int     `json:"fractal_min"`
+	FractalMax      int     `json:"fractal_max"`
+	FractalPrize    float64 `json:"fractal_prize"`
+	RoundsCount     int     `json:"rounds_count"`
+	RoundLength     int     `json:"round_length"`
+	VerificationMin int     `json:"verification_min"`
+	VerificationMax int     `json:"verification_max"`
+	BanCount        int     `json:"ban_count"`
+	Scheme          string  `json:"scheme,omitempty"`
+	KeySize         int     `json:"key_size"`
+	BadBehavior     float64 `json:"bad_behavior"`
+	GrindBudget     int     `json:"grind_budget,omitempty"`
+	BeaconTeams     bool    `json:"beacon_teams,omitempty"`
+}
+
+func DefaultParams() Params {
+	return Params{
+		FractalMin:      50,
+		FractalMax:      200,
+		FractalPrize:    5,
+		RoundsCount:     10,
+		RoundLength:     1000,
+		VerificationMin: 21,
+		VerificationMax: 21,
+		BanCount:        3,
+		Scheme:          tools.SchemeRSAPSS,
+		KeySize:         2048,
+		BadBehavior:     0.1,
+		GrindBudget:     16,
+	}
+}
+
+func (p Params) Validate() error {
+	if p.FractalMin < 1 || p.FractalMax < p.FractalMin {
+		return errors.New("fractal size range is invalid")
+	} else if p.FractalPrize < 0 {
+		return errors.New("fractal prize must be non-negative")
+	} else if p.RoundsCount < 1 {
+		return errors.New("rounds count must be positive")
+	} else if p.RoundLength < 1 {
+		return errors.New("round length must be positive")
+	} else if p.VerificationMin < 1 || p.VerificationMax < p.VerificationMin {
+		return errors.New("verification team size range is invalid")
+	} else if p.BanCount < 0 {
+		return errors.New("ban count must be non-negative")
+	} else if p.Scheme != "" && p.Scheme != tools.SchemeRSAPSS && p.Scheme != tools.SchemeEd25519 {
+		return errors.New("signature scheme must be rsa-pss or ed25519")
+	} else if (p.Scheme == "" || p.Scheme == tools.SchemeRSAPSS) && p.KeySize < 1024 {
+		return errors.New("key size must be at least 1024 bits")
+	} else if p.BadBehavior < 0 || p.BadBehavior > 1 {
+		return errors.New("bad behavior percentage must be between 0 and 1")
+	} else if p.GrindBudget < 0 {
+		return errors.New("grind budget must be non-negative")
+	}
+	return nil
+}
+
+func (p Params) RoundDuration() time.Duration {
+	return time.Duration(p.RoundLength) * time.Millisecond
+}
@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"slices"
+
+	"github.com/Arka-Lab/LoR/tools"
+	"golang.org/x/exp/maps"
+)
+
+var legacyBehaviors = []string{BehaviorNormal, BehaviorRandom, BehaviorBad}
+
+type CooperationState struct {
+	CooperationTable
+	UnusedCoins [][]int `json:"unused_coins,omitempty"`
+}
+
+type TraderState struct {
+	Behavior      string                      `json:"behavior"`
+	TraderType    *int                        `json:"trader_type,omitempty"`
+	CoinTypeCount uint                        `json:"coin_type_count"`
+	Params        Params                      `json:"params"`
+	PrivateKey    []byte                      `json:"private_key"`
+	Random        []byte                      `json:"random"`
+	Traders       map[string]Trader           `json:"traders"`
+	Coins         map[string]CoinTable        `json:"coins"`
+	Cooperations  map[string]CooperationState `json:"cooperations"`
+	BanUntil      int                         `json:"ban_until"`
+	Beacon        string                      `json:"beacon,omitempty"`
+}
+
+func (t *Trader) State() (TraderState, error) {
+	random, err := t.Data.Random.MarshalBinary()
+	if err != nil {
+		return TraderState{}, err
+	}
+	privateKey, err := t.Data.Signer.MarshalBinary()
+	if err != nil {
+		return TraderState{}, err
+	}
+
+	coinIDs := maps.Keys(t.Data.Coins)
+	slices.Sort(coinIDs)
+	cooperations := make(map[string]CooperationState, len(t.Data.Cooperations))
+	for id, cooperation := range t.Data.Cooperations {
+		state := CooperationState{CooperationTable: cooperation}
+		if cooperation.Next == "" && cooperation.Prev == "" {
+			unusedCoins, err := indexCoins(coinIDs, cooperation.UnusedCoins)
+			if err != nil {
+				return TraderState{}, err
+			}
+			state.UnusedCoins = unusedCoins
+		}
+		cooperations[id] = state
+	}
+
+	return TraderState{
+		Behavior:      t.Data.Behavior.Name(),
+		CoinTypeCount: t.Data.CoinTypeCount,
+		Params:        t.Data.Params,
+		PrivateKey:    privateKey,
+		Random:        random,
+		Traders:       t.Data.Traders,
+		Coins:         t.Data.Coins,
+		Cooperations:  cooperations,
+		BanUntil:      t.Data.BanUntil,
+		Beacon:        t.Data.Beacon,
+	}, nil
+}
+
+func (t *Trader) Restore(state TraderState, society *Society) error {
+	signer, err := tools.RestoreSigner(state.Params.Scheme, state.PrivateKey)
+	if err != nil {
+		return err
+	}
+	random, err := tools.RestoreRandom(state.Random)
+	if err != nil {
+		return err
+	}
+
+	name := state.Behavior
+	if name == "" && state.TraderType != nil && *state.TraderType >= 0 && *state.TraderType < len(legacyBehaviors) {
+		name = legacyBehaviors[*state.TraderType]
+	}
+	behavior, err := NewBehavior(name, society)
+	if err != nil {
+		return err
+	}
+
+	coinIDs := maps.Keys(state.Coins)
+	slices.Sort(coinIDs)
+	cooperations := make(map[string]CooperationTable, len(state.Cooperations))
+	for id, cooperation := range state.Cooperations {
+		unusedCoins, err := resolveCoins(coinIDs, cooperation.UnusedCoins)
+		if err != nil {
+			return err
+		}
+		cooperation.CooperationTable.UnusedCoins = unusedCoins
+		cooperations[id] = cooperation.CooperationTable
+	}
+
+	t.Data = &TraderData{
+		Behavior:      behavior,
+		CoinTypeCount: state.CoinTypeCount,
+		Params:        state.Params,
+		Signer:        signer,
+		Random:        random,
+		Traders:       state.Traders,
+		Coins:         state.Coins,
+		Cooperations:  cooperations,
+		BanUntil:      state.BanUntil,
+		Beacon:        state.Beacon,
+	}
+	return nil
+}
+
+func indexCoins(coinIDs []string, groups [][]string) ([][]int, error) {
+	result := make([][]int, len(groups))
+	for i, group := range groups {
+		result[i] = make([]int, len(group))
+		for j, coinID := range group {
+			index, ok := slices.BinarySearch(coinIDs, coinID)
+			if !ok {
+				return nil, coinError(ErrCoinNotFound, coinID)
+			}
+			result[i][j] = index
+		}
+	}
+	return result, nil
+}
+
+func resolveCoins(coinIDs []string, groups [][]int) ([][]string, error) {
+	if groups == nil {
+		return nil, nil
+	}
+
+	result := make([][]string, len(groups))
+	for i, group := range groups {
+		result[i] = make([]string, len(group))
+		for j, index := range group {
+			if index < 0 || index >= len(coinIDs) {
+				return nil, ErrCoinNotFound
+			}
+			result[i][j] = coinIDs[index]
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,14 @@
+package pkg
+
+const BehaviorSybil = "sybil"
+
+// Sybil is one of many wallets run by a single principal. Its wallets submit
+// invalid fractal rings with protocol-valid verification teams and vote
+// together, so the principal wins whenever it holds enough seats by chance.
+type Sybil struct {
+	Colluder
+}
+
+func (b Sybil) ChooseTeam(t *Trader, ring []string) ([]string, bool) {
+	return Honest{}.ChooseTeam(t, ring)
+}
@@ -1,69 +1,52 @@
 package pkg
 
 import (
-	"crypto/rsa"
-	"errors"
 	"strconv"
-	"time"
 
 	"github.com/Arka-Lab/LoR/tools"
 )
 
-const (
-	KeySize = 2048
-)
-
-var (
-	BadBehavior = 0.1
-)
-
-type BehaviorType int
-
-const (
-	Normal BehaviorType = iota
-	RandomVote
-	BadVote
-)
-
 type TraderData struct {
-	TraderType    BehaviorType
+	Behavior      Behavior
 	CoinTypeCount uint
-	Ticker        *time.Ticker
-	PrivateKey    *rsa.PrivateKey
+	Params        Params
+	Signer        tools.Signer
+	Random        *tools.Random
 	Traders       map[string]Trader
+	Neighbors     []string
+	Overlay       map[string][]string
 	Coins         map[string]CoinTable
 	Cooperations  map[string]CooperationTable
 	BanUntil      int
+	Beacon        string
 }
 
 type Trader struct {
-	ID        string         `json:"id"`
-	Account   float64        `json:"account"`
-	Wallet    string         `json:"wallet"`
-	PublicKey *rsa.PublicKey `json:"public_key"`
+	ID        string          `json:"id"`
+	Account   float64         `json:"account"`
+	Wallet    string          `json:"wallet"`
+	PublicKey tools.PublicKey `json:"public_key"`
 
 	Data *TraderData `json:"-"`
 }
 
-func CreateTrader(traderType BehaviorType, account float64, wallet string, coinTypeCount uint) *Trader {
-	privateKey, err := tools.GeneratePrivateKey(KeySize)
+func CreateTrader(params Params, behavior Behavior, account float64, wallet string, coinTypeCount uint, random *tools.Random) *Trader {
+	signer, err := tools.GenerateSigner(params.Scheme, random, params.KeySize)
 	if err != nil {
 		return nil
 	}
 
-	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
-	ticker := time.NewTicker(RoundLength * time.Millisecond)
-
 	return &Trader{
 		ID:        tools.SHA256Str(wallet + "-" + strconv.Itoa(int(coinTypeCount))),
 		Account:   account,
 		Wallet:    wallet,
-		PublicKey: &privateKey.PublicKey,
+		PublicKey: tools.PublicKey{Verifier: signer.Verifier()},
 		Data: &TraderData{
-			Ticker:        ticker,
-			TraderType:    traderType,
-			PrivateKey:    privateKey,
+			Behavior:      behavior,
+			Signer:        signer,
+			Random:        random,
 			CoinTypeCount: coinTypeCount,
+			Params:        params,
 			Traders:       make(map[string]Trader),
 			Coins:         make(map[string]CoinTable),
 			Cooperations:  make(map[string]CooperationTable),
@@ -75,35 +58,36 @@ func CreateTrader(traderType BehaviorType, account float64, wallet string, coinT
 func (t *Trader) SaveTrader(trader Trader) error {
 	trader.Data = nil
 	if _, ok := t.Data.Traders[trader.ID]; ok {
-		return errors.New("trader already exist")
+		return traderError(ErrTraderExists, trader.ID)
 	} else if trader.ID != tools.SHA256Str(trader.Wallet+"-"+strconv.Itoa(int(t.Data.CoinTypeCount))) {
-		return errors.New("invalid trader ID")
+		return traderError(ErrInvalidTraderID, trader.ID)
 	}
 
 	t.Data.Traders[trader.ID] = trader
 	return nil
 }
 
-func (t *Trader) CheckForRings(fractalCounter int) *FractalRing {
+func (t *Trader) CheckForRings(fractalCounter int) (*CooperationTable, *FractalRing) {
 	if cooperation := t.checkForCooperationRing(); cooperation != nil {
 		t.Data.Cooperations[cooperation.ID] = *cooperation
 		if t.Data.BanUntil <= fractalCounter {
-			return t.checkForFractalRing()
+			return cooperation, t.checkForFractalRing()
 		}
+		return cooperation, nil
 	}
-	return nil
+	return nil, nil
 }
 
 func (t *Trader) InformFractalRing(fractal FractalRing) error {
 	for _, cooperation := range fractal.CooperationRings {
 		for _, coinID := range cooperation.CoinIDs {
 			if coin, ok := t.Data.Coins[coinID]; !ok {
-				return errors.New("coin not found")
+				return coinError(ErrCoinNotFound, coinID)
 			} else if coin.Status != Run {
-				return errors.New("coin is not running")
+				return coinError(ErrCoinNotRunning, coinID)
 			} else if coin.CooperationID != "" && coin.CooperationID != cooperation.ID {
 				if ring, ok := t.Data.Cooperations[coin.CooperationID]; !ok {
-					return errors.New("cooperating not found")
+					return cooperationError(ErrCooperationNotFound, coin.CooperationID)
 				} else if ring.FractalID != "" {
 					t.RemoveFractalRing(ring.FractalID)
 				} else {
@@ -118,6 +102,7 @@ func (t *Trader) InformFractalRing(fractal FractalRing) error {
 }
 
 func (t *Trader) saveFractalRing(fractal FractalRing) {
+	t.Data.Beacon = NextBeacon(t.Data.Beacon, fractal.ID)
 	for _, cooperation := range fractal.CooperationRings {
 		selectedCoins := cooperation.CoinIDs
 		t.Data.Cooperations[cooperation.ID] = cooperation
@@ -154,9 +139,9 @@ func (t *Trader) removeCooperatinRing(cooperationID string) {
 
 func (t *Trader) UpdateBalance(traderID string, amount float64) error {
 	if trader, ok := t.Data.Traders[traderID]; !ok {
-		return errors.New("trader not found")
+		return traderError(ErrTraderNotFound, traderID)
 	} else if trader.Account+amount < 0 {
-		return errors.New("insufficient account")
+		return traderError(ErrInsufficientAccount, traderID)
 	} else {
 		trader.Account += amount
 		t.Data.Traders[traderID] = trader
@@ -0,0 +1,128 @@
+package pkg
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/Arka-Lab/LoR/tools"
+)
+
+const (
+	uniformitySeed  = 1
+	uniformityAlpha = 0.001
+)
+
+// TestUniformity draws many verification teams, fractal rings and
+// cooperation rings with the protocol's selection code and checks with a
+// chi-square test that every candidate, and every size, is equally likely.
+func TestUniformity(t *testing.T) {
+	trials := 20000
+	if testing.Short() {
+		trials = 2000
+	}
+	random := tools.NewRandom(uniformitySeed)
+	ids := func(prefix string, n int) []string {
+		result := make([]string, n)
+		for i := range result {
+			result[i] = tools.SHA256Str(prefix + strconv.Itoa(i))
+		}
+		slices.Sort(result)
+		return result
+	}
+
+	params := DefaultParams()
+	params.VerificationMin, params.VerificationMax = 15, 27
+	params.FractalMin, params.FractalMax = 20, 60
+	traders, rings := ids("trader-", 97), ids("ring-", 211)
+	coins := [][]string{nil, ids("coin-1-", 13), ids("coin-2-", 31)}
+
+	members, positions := make([]int, len(traders)), make([]int, len(traders))
+	teamSizes := make([]int, params.VerificationMax-params.VerificationMin+1)
+	chosen, fractalSizes := make([]int, len(rings)), make([]int, params.FractalMax-params.FractalMin+1)
+	types := [][]int{make([]int, len(coins[1])), make([]int, len(coins[2]))}
+	count := func(counts []int, pool []string, id string) {
+		if index, ok := slices.BinarySearch(pool, id); ok {
+			counts[index]++
+		}
+	}
+
+	// Everything after the first member is derived from it, so every trial
+	// starts from a fresh first member to keep the draws independent.
+	for trial := 0; trial < trials; trial++ {
+		first := "first-" + strconv.Itoa(trial)
+
+		team := selectVerificationTeam(random, params, append(slices.Clone(traders), first), []string{first}, first)
+		teamSizes[len(team)-params.VerificationMin]++
+		for _, traderID := range team[1:] {
+			count(members, traders, traderID)
+		}
+		count(positions, traders, team[len(team)/2])
+
+		fractal := selectFractalRing(random, params, append(slices.Clone(rings), first), first)
+		fractalSizes[len(fractal)-params.FractalMin]++
+		for _, ringID := range fractal[1:] {
+			count(chosen, rings, ringID)
+		}
+
+		cooperation := selectCooperationRing(random, [][]string{{first}, coins[1], coins[2]}, first)
+		for i, coinID := range cooperation[1:] {
+			count(types[i], coins[i+1], coinID)
+		}
+	}
+
+	for _, check := range []struct {
+		name   string
+		counts []int
+	}{
+		{"team members", members},
+		{"team member at a fixed position", positions},
+		{"team size", teamSizes},
+		{"fractal ring members", chosen},
+		{"fractal ring size", fractalSizes},
+		{"cooperation ring coin of type 1", types[0]},
+		{"cooperation ring coin of type 2", types[1]},
+	} {
+		t.Run(check.name, func(t *testing.T) {
+			if statistic, dof, p := chiSquare(check.counts); p < uniformityAlpha {
+				t.Errorf("chi-square %.2f with %d degrees of freedom, p-value %.4f below %v", statistic, dof, p, uniformityAlpha)
+			}
+		})
+	}
+}
+
+func TestDRBGUniformity(t *testing.T) {
+	drbg := tools.NewDRBG(uniformitySeed)
+	for _, n := range []int{2, 7, 10, 97} {
+		counts := make([]int, n)
+		for i := 0; i < 1000*n; i++ {
+			counts[drbg.IntN(n)]++
+		}
+		if statistic, dof, p := chiSquare(counts); p < uniformityAlpha {
+			t.Errorf("IntN(%d): chi-square %.2f with %d degrees of freedom, p-value %.4f below %v", n, statistic, dof, p, uniformityAlpha)
+		}
+	}
+}
+
+// chiSquare compares observed counts with a uniform distribution over the
+// same cells and returns the statistic, its degrees of freedom and the
+// p-value from the Wilson-Hilferty approximation.
+func chiSquare(counts []int) (float64, int, float64) {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	expected := float64(total) / float64(len(counts))
+
+	statistic := 0.
+	for _, count := range counts {
+		diff := float64(count) - expected
+		statistic += diff * diff / expected
+	}
+
+	dof := len(counts) - 1
+	k := float64(dof)
+	z := (math.Cbrt(statistic/k) - (1 - 2/(9*k))) / math.Sqrt(2/(9*k))
+	return statistic, dof, math.Erfc(z/math.Sqrt2) / 2
+}
@@ -1,56 +1,39 @@
 package pkg
 
 import (
-	"errors"
 	"slices"
 
-	"golang.org/x/exp/rand"
-
 	"github.com/Arka-Lab/LoR/tools"
 )
 
-const (
-	VerificationMin = 21
-	VerificationMax = 21
-	BanCount        = 3
-)
-
 func (t *Trader) SubmitRing(ring *FractalRing) error {
 	if err := t.validateFractalRing(ring); err != nil {
-		validErrors := []string{"invalid selected cooperation ring", "invalid verification team", "invalid cooperation ring coins"}
-		if !slices.Contains(validErrors, err.Error()) {
-			return err
-		}
-
-		if t.Data.TraderType == BadVote || (t.Data.TraderType == RandomVote && rand.Float64() < BadBehavior) {
-			return nil
-		}
-		return err
+		return t.Data.Behavior.ValidateSubmission(t, ring, err)
 	}
-	return t.Vote()
+	return t.Vote(ring, -1, -1)
 }
 
-func (t *Trader) Vote() error {
-	if t.Data.TraderType == BadVote || (t.Data.TraderType == RandomVote && rand.Float64() < BadBehavior) {
-		return errors.New("bad behavior")
+func (t *Trader) Vote(fractal *FractalRing, ring, round int) error {
+	if !t.Data.Behavior.Vote(t, fractal, ring, round) {
+		return fractalError(ErrBadBehavior, fractal.ID)
 	}
 	return nil
 }
 
-func selectRandomVerification(traders []string) (result []string) {
-	if len(traders) < VerificationMin {
+func selectRandomVerification(random *tools.Random, params Params, traders []string) (result []string) {
+	if len(traders) < params.VerificationMin {
 		return nil
 	}
 
-	randomIndices := tools.RandomIndexes(len(traders), rand.Intn(min(VerificationMax, len(traders))-VerificationMin+1)+VerificationMin)
+	randomIndices := tools.RandomIndexes(random, len(traders), random.IntN(min(params.VerificationMax, len(traders))-params.VerificationMin+1)+params.VerificationMin)
 	for _, index := range randomIndices {
 		result = append(result, traders[index])
 	}
 	return
 }
 
-func selectVerificationTeam(traders []string, ring []string, firstOne string) (team []string) {
-	k := VerificationMin + tools.SHA256Int(ring)%(VerificationMax-VerificationMin+1)
+func selectVerificationTeam(random *tools.Random, params Params, traders []string, ring []string, firstOne string) (team []string) {
+	k := teamSize(params, ring)
 	if len(traders) < k {
 		return nil
 	}
@@ -70,23 +53,17 @@ func selectVerificationTeam(traders []string, ring []string, firstOne string) (t
 			}
 		}
 	} else {
-		index := rand.Intn(len(traders))
+		index := random.IntN(len(traders))
 		team[0] = copiedTraders[index]
 
 		copiedTraders[index] = copiedTraders[0]
 		copiedTraders = copiedTraders[1:]
 	}
 
-	rnd := make([]int, 0)
-	for i := 1; i < k; i++ {
-		if len(rnd) == 0 {
-			rnd = tools.SHA256Arr(team)
-		}
-		index := rnd[0] % len(copiedTraders)
-		team[i], rnd = copiedTraders[index], rnd[1:]
-
-		copiedTraders[index] = copiedTraders[0]
-		copiedTraders = copiedTraders[1:]
-	}
+	tools.FillSample(team, copiedTraders)
 	return
 }
+
+func teamSize(params Params, ring []string) int {
+	return params.VerificationMin + tools.NewDRBG(ring).IntN(params.VerificationMax-params.VerificationMin+1)
+}
@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"encoding/binary"
+	"math"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// DRBG is a deterministic random bit generator that expands the SHA3-256
+// digest of its seed in counter mode, so anyone who knows the seed can repeat
+// every draw.
+type DRBG struct {
+	seed    []byte
+	counter uint64
+	block   []byte
+}
+
+func NewDRBG(data interface{}) *DRBG {
+	return &DRBG{seed: SHA256(data)}
+}
+
+func (d *DRBG) Uint64() uint64 {
+	if len(d.block) < 8 {
+		h := sha3.New256()
+		h.Write(d.seed)
+		h.Write(binary.BigEndian.AppendUint64(nil, d.counter))
+		d.block, d.counter = h.Sum(nil), d.counter+1
+	}
+	value := binary.BigEndian.Uint64(d.block)
+	d.block = d.block[8:]
+	return value
+}
+
+// IntN returns a uniform integer in [0, n) by rejecting the draws that would
+// make some remainders more likely than others.
+func (d *DRBG) IntN(n int) int {
+	if n <= 0 {
+		panic("invalid argument to IntN")
+	}
+	bound := uint64(n)
+	threshold := (math.MaxUint64 - bound + 1) % bound
+	for {
+		if value := d.Uint64(); value >= threshold {
+			return int(value % bound)
+		}
+	}
+}
+
+// FillSample fills result[1:] with distinct elements of pool, which must not
+// hold result[0], drawing them with a DRBG seeded by result itself. The pool
+// is reordered in place.
+func FillSample(result, pool []string) {
+	drbg := NewDRBG(result)
+	for i := 1; i < len(result); i++ {
+		index := drbg.IntN(len(pool))
+		result[i] = pool[index]
+		pool[index] = pool[0]
+		pool = pool[1:]
+	}
+}
@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"encoding/binary"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestDRBGDeterministic(t *testing.T) {
+	a, b := NewDRBG("seed"), NewDRBG("seed")
+	for i := 0; i < 100; i++ {
+		if x, y := a.Uint64(), b.Uint64(); x != y {
+			t.Fatalf("draw %d: %d != %d", i, x, y)
+		}
+	}
+	if NewDRBG("seed").Uint64() == NewDRBG("other").Uint64() {
+		t.Error("different seeds gave the same first draw")
+	}
+}
+
+// TestDRBGIntNRejection feeds IntN draws around its rejection threshold,
+// 2^64 mod n, for bounds up to the largest int.
+func TestDRBGIntNRejection(t *testing.T) {
+	for _, n := range []int{1, 3, 1 << 32, 1<<62 + 1, 1 << 62, 3 << 61, math.MaxInt64 - 1, math.MaxInt64} {
+		threshold := new(big.Int).Mod(new(big.Int).Lsh(big.NewInt(1), 64), big.NewInt(int64(n))).Uint64()
+		drbg := &DRBG{}
+		if threshold > 0 {
+			drbg.block = binary.BigEndian.AppendUint64(drbg.block, 0)
+			drbg.block = binary.BigEndian.AppendUint64(drbg.block, threshold-1)
+		}
+		drbg.block = binary.BigEndian.AppendUint64(drbg.block, threshold)
+		if got := drbg.IntN(n); got != int(threshold%uint64(n)) {
+			t.Errorf("IntN(%d) = %d, want the first draw at the threshold %d", n, got, threshold)
+		} else if len(drbg.block) != 0 {
+			t.Errorf("IntN(%d) accepted a draw below the threshold %d", n, threshold)
+		}
+
+		drbg.block = binary.BigEndian.AppendUint64(nil, math.MaxUint64)
+		if got := drbg.IntN(n); got < 0 || got >= n {
+			t.Errorf("IntN(%d) = %d out of range", n, got)
+		}
+	}
+}
+
+func TestDRBGIntNPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("IntN(0) did not panic")
+		}
+	}()
+	NewDRBG("panic").IntN(0)
+}
@@ -1,54 +1,106 @@
 package tools
 
 import (
-	"crypto"
-	crand "crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
-	"math/rand"
+	"errors"
+	"io"
+	"math/big"
+	"math/rand/v2"
 )
 
-func RandomIndexes(n, k int) (result []int) {
-	rnd := make([]int, 0)
-	result = append(result, rand.Intn(n))
-	for i := 1; i < k; i++ {
-		if len(rnd) == 0 {
-			rnd = SHA256Arr(result)
-		}
-		result, rnd = append(result, rnd[0]%(n-i)), rnd[1:]
-	}
-	return
+type Random struct {
+	*rand.Rand
+	source *rand.ChaCha8
 }
 
-func GeneratePrivateKey(size int) (*rsa.PrivateKey, error) {
-	privateKey, err := rsa.GenerateKey(crand.Reader, size)
-	if err != nil {
-		return nil, err
-	}
-	if err := privateKey.Validate(); err != nil {
+func NewRandom(seed uint64) *Random {
+	source := rand.NewChaCha8([32]byte(SHA256(seed)))
+	return &Random{Rand: rand.New(source), source: source}
+}
+
+func (r *Random) Read(p []byte) (int, error) {
+	return r.source.Read(p)
+}
+
+func (r *Random) Child() *Random {
+	return NewRandom(r.Uint64())
+}
+
+func (r *Random) MarshalBinary() ([]byte, error) {
+	return r.source.MarshalBinary()
+}
+
+func RestoreRandom(data []byte) (*Random, error) {
+	source := new(rand.ChaCha8)
+	if err := source.UnmarshalBinary(data); err != nil {
 		return nil, err
 	}
-	return privateKey, nil
+	return &Random{Rand: rand.New(source), source: source}, nil
 }
 
-func SignWithPrivateKey(data []byte, privateKey *rsa.PrivateKey) ([]byte, error) {
-	hashed := sha256.Sum256(data)
-	return rsa.SignPSS(crand.Reader, privateKey, crypto.SHA256, hashed[:], nil)
+func RandomIndexes(random *Random, n, k int) []int {
+	return random.Perm(n)[:k]
 }
 
-func VerifyWithPublicKey(data []byte, signature []byte, publicKey *rsa.PublicKey) error {
-	hashed := sha256.Sum256(data)
-	return rsa.VerifyPSS(publicKey, crypto.SHA256, hashed[:], signature, nil)
-}
+func GeneratePrivateKey(random io.Reader, size int) (*rsa.PrivateKey, error) {
+	e := big.NewInt(65537)
+	one := big.NewInt(1)
+	for {
+		p, err := generatePrime(random, size-size/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := generatePrime(random, size/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
 
-func SignWithPrivateKeyStr(data string, privateKey *rsa.PrivateKey) (string, error) {
-	signature, err := SignWithPrivateKey([]byte(data), privateKey)
-	if err != nil {
-		return "", err
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != size {
+			continue
+		}
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
+
+		privateKey := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		privateKey.Precompute()
+		if err := privateKey.Validate(); err != nil {
+			return nil, err
+		}
+		return privateKey, nil
 	}
-	return string(signature), nil
 }
 
-func VerifyWithPublicKeyStr(data string, signature string, publicKey *rsa.PublicKey) error {
-	return VerifyWithPublicKey([]byte(data), []byte(signature), publicKey)
+func generatePrime(random io.Reader, bits int) (*big.Int, error) {
+	if bits < 2 {
+		return nil, errors.New("prime size must be at least 2 bits")
+	}
+
+	data := make([]byte, (bits+7)/8)
+	extra := uint(len(data)*8 - bits)
+	for {
+		if _, err := io.ReadFull(random, data); err != nil {
+			return nil, err
+		}
+		data[0] &= byte(0xff >> extra)
+		data[0] |= byte(0xc0 >> extra)
+		if extra == 7 {
+			data[1] |= 0x80
+		}
+		data[len(data)-1] |= 1
+
+		if p := new(big.Int).SetBytes(data); p.ProbablyPrime(20) {
+			return p, nil
+		}
+	}
 }
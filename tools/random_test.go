@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"strconv"
+	"testing"
+)
+
+func TestGeneratePrivateKey(t *testing.T) {
+	for _, size := range []int{1024, 2048} {
+		t.Run(strconv.Itoa(size), func(t *testing.T) {
+			key, err := GeneratePrivateKey(NewRandom(1), size)
+			if err != nil {
+				t.Fatal(err)
+			} else if err := key.Validate(); err != nil {
+				t.Fatal(err)
+			} else if key.N.BitLen() != size {
+				t.Errorf("modulus has %d bits", key.N.BitLen())
+			}
+
+			if same, err := GeneratePrivateKey(NewRandom(1), size); err != nil {
+				t.Fatal(err)
+			} else if !key.Equal(same) {
+				t.Error("the same seed gave different keys")
+			}
+			if other, err := GeneratePrivateKey(NewRandom(2), size); err != nil {
+				t.Fatal(err)
+			} else if key.Equal(other) {
+				t.Error("different seeds gave the same key")
+			}
+
+			hashed := sha256.Sum256([]byte("trader-0"))
+			signature, err := rsa.SignPSS(NewRandom(3), key, crypto.SHA256, hashed[:], nil)
+			if err != nil {
+				t.Fatal(err)
+			} else if err := rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hashed[:], signature, nil); err != nil {
+				t.Error(err)
+			}
+			tampered := sha256.Sum256([]byte("trader-1"))
+			if rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, tampered[:], signature, nil) == nil {
+				t.Error("signature verified for other data")
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"container/heap"
+	"time"
+)
+
+type event struct {
+	at     time.Duration
+	seq    uint64
+	action func()
+}
+
+type eventQueue []event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x any) { *q = append(*q, x.(event)) }
+
+func (q *eventQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+type Scheduler struct {
+	now   time.Duration
+	seq   uint64
+	queue eventQueue
+}
+
+func NewScheduler(now time.Duration) *Scheduler {
+	return &Scheduler{now: now}
+}
+
+func (s *Scheduler) Now() time.Duration {
+	return s.now
+}
+
+func (s *Scheduler) Pending() int {
+	return len(s.queue)
+}
+
+func (s *Scheduler) At(at time.Duration, action func()) {
+	if at < s.now {
+		at = s.now
+	}
+	s.seq++
+	heap.Push(&s.queue, event{at: at, seq: s.seq, action: action})
+}
+
+func (s *Scheduler) After(delay time.Duration, action func()) {
+	s.At(s.now+delay, action)
+}
+
+// Next returns the time of the earliest pending event.
+func (s *Scheduler) Next() (time.Duration, bool) {
+	if len(s.queue) == 0 {
+		return 0, false
+	}
+	return s.queue[0].at, true
+}
+
+func (s *Scheduler) Step() bool {
+	if len(s.queue) == 0 {
+		return false
+	}
+	e := heap.Pop(&s.queue).(event)
+	s.now = e.at
+	e.action()
+	return true
+}
+
+func (s *Scheduler) RunUntil(end time.Duration) {
+	for len(s.queue) > 0 && s.queue[0].at <= end {
+		s.Step()
+	}
+	if s.now < end {
+		s.now = end
+	}
+}
+
+func (s *Scheduler) Run() {
+	for s.Step() {
+	}
+}
@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+const (
+	SchemeRSAPSS  = "rsa-pss"
+	SchemeEd25519 = "ed25519"
+)
+
+type Verifier interface {
+	Scheme() string
+	Verify(data, signature []byte) error
+	MarshalBinary() ([]byte, error)
+}
+
+type Signer interface {
+	Scheme() string
+	Sign(data []byte, random io.Reader) ([]byte, error)
+	Verifier() Verifier
+	MarshalBinary() ([]byte, error)
+}
+
+// PublicKey wraps a Verifier so that it can be stored in snapshots together
+// with the name of its scheme.
+type PublicKey struct {
+	Verifier
+}
+
+type publicKeyJSON struct {
+	Scheme string `json:"scheme"`
+	Key    []byte `json:"key"`
+}
+
+func GenerateSigner(scheme string, random io.Reader, keySize int) (Signer, error) {
+	switch scheme {
+	case SchemeRSAPSS, "":
+		privateKey, err := GeneratePrivateKey(random, keySize)
+		if err != nil {
+			return nil, err
+		}
+		return rsaSigner{privateKey}, nil
+	case SchemeEd25519:
+		seed := make([]byte, ed25519.SeedSize)
+		if _, err := io.ReadFull(random, seed); err != nil {
+			return nil, err
+		}
+		return ed25519Signer(ed25519.NewKeyFromSeed(seed)), nil
+	default:
+		return nil, fmt.Errorf("unknown signature scheme %s", scheme)
+	}
+}
+
+func RestoreSigner(scheme string, data []byte) (Signer, error) {
+	switch scheme {
+	case SchemeRSAPSS, "":
+		privateKey, err := x509.ParsePKCS1PrivateKey(data)
+		if err != nil {
+			return nil, err
+		}
+		return rsaSigner{privateKey}, nil
+	case SchemeEd25519:
+		if len(data) != ed25519.SeedSize {
+			return nil, errors.New("invalid ed25519 seed")
+		}
+		return ed25519Signer(ed25519.NewKeyFromSeed(data)), nil
+	default:
+		return nil, fmt.Errorf("unknown signature scheme %s", scheme)
+	}
+}
+
+func RestoreVerifier(scheme string, data []byte) (Verifier, error) {
+	switch scheme {
+	case SchemeRSAPSS, "":
+		publicKey, err := x509.ParsePKCS1PublicKey(data)
+		if err != nil {
+			return nil, err
+		}
+		return rsaVerifier{publicKey}, nil
+	case SchemeEd25519:
+		if len(data) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 public key")
+		}
+		return ed25519Verifier(data), nil
+	default:
+		return nil, fmt.Errorf("unknown signature scheme %s", scheme)
+	}
+}
+
+func SignStr(signer Signer, data string, random io.Reader) (string, error) {
+	signature, err := signer.Sign([]byte(data), random)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(signature), nil
+}
+
+func VerifyStr(verifier Verifier, data string, signature string) error {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+	return verifier.Verify([]byte(data), decoded)
+}
+
+func (key PublicKey) MarshalJSON() ([]byte, error) {
+	if key.Verifier == nil {
+		return []byte("null"), nil
+	}
+	data, err := key.Verifier.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(publicKeyJSON{Scheme: key.Scheme(), Key: data})
+}
+
+func (key *PublicKey) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		key.Verifier = nil
+		return nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if _, ok := fields["N"]; ok {
+		var legacy struct {
+			N *big.Int
+			E int
+		}
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+		key.Verifier = rsaVerifier{&rsa.PublicKey{N: legacy.N, E: legacy.E}}
+		return nil
+	}
+
+	var encoded publicKeyJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+	verifier, err := RestoreVerifier(encoded.Scheme, encoded.Key)
+	if err != nil {
+		return err
+	}
+	key.Verifier = verifier
+	return nil
+}
+
+type rsaSigner struct {
+	privateKey *rsa.PrivateKey
+}
+
+type rsaVerifier struct {
+	publicKey *rsa.PublicKey
+}
+
+func (s rsaSigner) Scheme() string {
+	return SchemeRSAPSS
+}
+
+func (s rsaSigner) Sign(data []byte, random io.Reader) ([]byte, error) {
+	hashed := sha256.Sum256(data)
+	return rsa.SignPSS(random, s.privateKey, crypto.SHA256, hashed[:], nil)
+}
+
+func (s rsaSigner) Verifier() Verifier {
+	return rsaVerifier{&s.privateKey.PublicKey}
+}
+
+func (s rsaSigner) MarshalBinary() ([]byte, error) {
+	return x509.MarshalPKCS1PrivateKey(s.privateKey), nil
+}
+
+func (v rsaVerifier) Scheme() string {
+	return SchemeRSAPSS
+}
+
+func (v rsaVerifier) Verify(data, signature []byte) error {
+	hashed := sha256.Sum256(data)
+	return rsa.VerifyPSS(v.publicKey, crypto.SHA256, hashed[:], signature, nil)
+}
+
+func (v rsaVerifier) MarshalBinary() ([]byte, error) {
+	return x509.MarshalPKCS1PublicKey(v.publicKey), nil
+}
+
+type ed25519Signer ed25519.PrivateKey
+
+type ed25519Verifier ed25519.PublicKey
+
+func (s ed25519Signer) Scheme() string {
+	return SchemeEd25519
+}
+
+func (s ed25519Signer) Sign(data []byte, random io.Reader) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(s), data), nil
+}
+
+func (s ed25519Signer) Verifier() Verifier {
+	return ed25519Verifier(ed25519.PrivateKey(s).Public().(ed25519.PublicKey))
+}
+
+func (s ed25519Signer) MarshalBinary() ([]byte, error) {
+	return ed25519.PrivateKey(s).Seed(), nil
+}
+
+func (v ed25519Verifier) Scheme() string {
+	return SchemeEd25519
+}
+
+func (v ed25519Verifier) Verify(data, signature []byte) error {
+	if !ed25519.Verify(ed25519.PublicKey(v), data, signature) {
+		return errors.New("ed25519: verification error")
+	}
+	return nil
+}
+
+func (v ed25519Verifier) MarshalBinary() ([]byte, error) {
+	return []byte(v), nil
+}
@@ -0,0 +1,63 @@
+package tools
+
+import "testing"
+
+var schemes = []string{SchemeRSAPSS, SchemeEd25519}
+
+const benchKeySize = 2048
+
+func BenchmarkGenerateKey(b *testing.B) {
+	for _, scheme := range schemes {
+		b.Run(scheme, func(b *testing.B) {
+			random := NewRandom(1)
+			for i := 0; i < b.N; i++ {
+				if _, err := GenerateSigner(scheme, random, benchKeySize); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSign(b *testing.B) {
+	data := []byte("trader-0")
+	for _, scheme := range schemes {
+		b.Run(scheme, func(b *testing.B) {
+			random := NewRandom(1)
+			signer, err := GenerateSigner(scheme, random, benchKeySize)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := signer.Sign(data, random); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkVerify(b *testing.B) {
+	data := []byte("trader-0")
+	for _, scheme := range schemes {
+		b.Run(scheme, func(b *testing.B) {
+			random := NewRandom(1)
+			signer, err := GenerateSigner(scheme, random, benchKeySize)
+			if err != nil {
+				b.Fatal(err)
+			}
+			signature, err := signer.Sign(data, random)
+			if err != nil {
+				b.Fatal(err)
+			}
+			verifier := signer.Verifier()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := verifier.Verify(data, signature); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}